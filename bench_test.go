@@ -0,0 +1,94 @@
+package goml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jakubDoka/goml/goss"
+)
+
+var benchDocument = []byte(strings.Repeat(`
+<div class=["panel" "wide"] id="main" style="margin: 10 5; color: red;">
+	Some text that is long enough to be realistic, with punctuation.
+	<button onclick="submit" label="Ok" tooltip="Confirms the dialog"/>
+	<button onclick="cancel" label="Cancel" tooltip="Closes the dialog"/>
+	<div class=["row"]>
+		<span kind="title">Title</>
+		<span kind="value">Value with escape\n</>
+	</>
+</>
+`, 20))
+
+var benchPrefabs = []byte(`
+<!field>
+	<div class=["field"]>
+		<span kind="title">{title="Untitled"}</>
+		<span kind="value">{children}</>
+	</>
+<!/>
+`)
+
+var benchPrefabDocument = []byte(strings.Repeat(`
+<div>
+	<field title="Name">Jakub</>
+	<field title="Age">20</>
+	<field>unnamed</>
+</>
+`, 20))
+
+func benchParser(b *testing.B) *Parser {
+	p := NParser(&goss.Parser{})
+	p.AddDefinitions("div", "button", "span")
+	if err := p.AddPrefabs(benchPrefabs); err != nil {
+		b.Fatal(err)
+	}
+	return p
+}
+
+func BenchmarkParse(b *testing.B) {
+	p := benchParser(b)
+	b.SetBytes(int64(len(benchDocument)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Parse(benchDocument); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseRelease(b *testing.B) {
+	p := benchParser(b)
+	b.SetBytes(int64(len(benchDocument)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		root, err := p.Parse(benchDocument)
+		if err != nil {
+			b.Fatal(err)
+		}
+		Release(root)
+	}
+}
+
+func BenchmarkParsePrefabs(b *testing.B) {
+	p := benchParser(b)
+	b.SetBytes(int64(len(benchPrefabDocument)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Parse(benchPrefabDocument); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRegistryParse(b *testing.B) {
+	r := benchParser(b).Freeze()
+	b.SetBytes(int64(len(benchDocument)))
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := r.Parse(benchDocument); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
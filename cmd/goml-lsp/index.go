@@ -0,0 +1,248 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/jakubDoka/gogen/str"
+	"github.com/jakubDoka/goml"
+)
+
+// commentMark starts and ends goml comment
+var commentMark = string(goml.CommentEnd)
+
+// prefab is prefab definition found in source
+type prefab struct {
+	name, doc string
+	params    []string
+	loc       location
+}
+
+// detail returns usage of the prefab with all parameters
+func (p prefab) detail() string {
+	var sb strings.Builder
+	sb.WriteString("<" + p.name)
+	for _, param := range p.params {
+		sb.WriteString(" " + param + `=""`)
+	}
+	sb.WriteString("/>")
+	return sb.String()
+}
+
+// indexPrefabs finds prefab definitions in goml source. Scanning is purely syntactic
+// so it works on sources with errors. Comment that directly precedes prefab
+// definition is used as its documentation.
+func indexPrefabs(uri, src string) (prefabs []prefab) {
+	current := -1
+	commentEnd, comment := -1, ""
+	for i := 0; i < len(src); {
+		switch {
+		case strings.HasPrefix(src[i:], commentMark):
+			end := strings.Index(src[i+3:], commentMark)
+			if end == -1 {
+				return
+			}
+			comment = strings.TrimSpace(src[i+3 : i+3+end])
+			i += end + 6
+			commentEnd = i
+		case strings.HasPrefix(src[i:], "<!/>"):
+			current = -1
+			i += 4
+		case strings.HasPrefix(src[i:], "<!"):
+			name := ident(src[i+2:])
+			if name != "" {
+				p := prefab{name: name, loc: location{uri, span(src, i+2, i+2+len(name))}}
+				if commentEnd != -1 && strings.TrimSpace(src[commentEnd:i]) == "" {
+					p.doc = comment
+				}
+				prefabs = append(prefabs, p)
+				current = len(prefabs) - 1
+			}
+			i += 2 + len(name)
+		case src[i] == '\\':
+			i += 2
+		case strings.HasPrefix(src[i:], "{{"):
+			i += 2
+		case src[i] == '{':
+			i++
+			if name := ident(src[i:]); current != -1 && name != "" && name != goml.ChildrenSlot {
+				p := &prefabs[current]
+				if !contains(p.params, name) {
+					p.params = append(p.params, name)
+				}
+			}
+		default:
+			i++
+		}
+	}
+	return
+}
+
+// context describes what is written at cursor
+type context struct {
+	// element is true when cursor is placed at element name
+	element bool
+	// tag is name of element whose head contains cursor, it is empty when
+	// cursor is not in element head
+	tag string
+	// attribute is true when cursor is placed where attribute name can be written
+	attribute bool
+	// prefix is part of identifier before cursor
+	prefix string
+}
+
+// cursorContext analyzes source before cursor at offset
+func cursorContext(src string, offset int) (ctx context) {
+	const (
+		outside = iota
+		name
+		head
+		quoted
+		comment
+		directive
+	)
+
+	state, start := outside, 0
+	for i := 0; i < offset; i++ {
+		switch state {
+		case outside:
+			switch {
+			case src[i] == '\\':
+				i++
+			case strings.HasPrefix(src[i:], commentMark):
+				state = comment
+				i += 2
+			case strings.HasPrefix(src[i:], "<@"):
+				state = directive
+			case src[i] == '<':
+				state, start = name, i+1
+			}
+		case name:
+			switch src[i] {
+			case '!', '/':
+				state = outside
+			case ' ', '\n', '\t', '\r':
+				ctx.tag = src[start:i]
+				state = head
+			case '>':
+				state = outside
+			}
+		case head:
+			switch src[i] {
+			case '"':
+				state = quoted
+			case '>':
+				state = outside
+			}
+		case quoted:
+			switch src[i] {
+			case '\\':
+				i++
+			case '"':
+				state = head
+			}
+		case comment:
+			if strings.HasPrefix(src[i:], commentMark) {
+				state = outside
+				i += 2
+			}
+		case directive:
+			if src[i] == '>' {
+				state = outside
+			}
+		}
+	}
+
+	ctx.prefix = identBefore(src, offset)
+	switch state {
+	case name:
+		ctx.prefix = src[start:offset]
+		if ident(ctx.prefix) != ctx.prefix {
+			return context{}
+		}
+		ctx.tag, ctx.element = "", true
+	case head:
+		before := offset - len(ctx.prefix)
+		ctx.attribute = before > 0 && (src[before-1] == ' ' || src[before-1] == '\n' || src[before-1] == '\t')
+	default:
+		return context{}
+	}
+	return ctx
+}
+
+// ident returns identifier at the start of s
+func ident(s string) string {
+	for i := 0; i < len(s); i++ {
+		if !str.IsIdent(s[i]) {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// identBefore returns identifier that ends at offset
+func identBefore(s string, offset int) string {
+	i := offset
+	for i > 0 && str.IsIdent(s[i-1]) {
+		i--
+	}
+	return s[i:offset]
+}
+
+// identAt returns identifier containing offset and its bounds
+func identAt(s string, offset int) (string, int, int) {
+	start := offset - len(identBefore(s, offset))
+	end := offset + len(ident(s[offset:]))
+	return s[start:end], start, end
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+// toPosition converts byte offset into lsp position that counts characters
+// in utf-16 code units
+func toPosition(src string, offset int) position {
+	if offset > len(src) {
+		offset = len(src)
+	}
+	line := strings.Count(src[:offset], "\n")
+	lineStart := strings.LastIndexByte(src[:offset], '\n') + 1
+	return position{line, utf16Len(src[lineStart:offset])}
+}
+
+// toOffset converts lsp position into byte offset
+func toOffset(src string, pos position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(src[offset:], '\n')
+		if i == -1 {
+			return len(src)
+		}
+		offset += i + 1
+	}
+	for units := 0; offset < len(src) && src[offset] != '\n'; {
+		r, size := utf8.DecodeRuneInString(src[offset:])
+		units += len(utf16.Encode([]rune{r}))
+		if units > pos.Character {
+			break
+		}
+		offset += size
+	}
+	return offset
+}
+
+// span converts byte bounds into lsp range
+func span(src string, start, end int) textRange {
+	return textRange{toPosition(src, start), toPosition(src, end)}
+}
+
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
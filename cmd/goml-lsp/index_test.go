@@ -0,0 +1,170 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/jakubDoka/goml/core"
+)
+
+func TestIndexPrefabs(t *testing.T) {
+	testCases := []struct {
+		desc, input string
+		output      []prefab
+	}{
+		{
+			desc:  "documented",
+			input: "<#> Button with label <#>\n<!btn><div a={label} b=[\"x\" {kind=\"a\"}]>{label} {{x}</><!/>",
+			output: []prefab{{
+				name:   "btn",
+				doc:    "Button with label",
+				params: []string{"label", "kind"},
+				loc:    location{"u", textRange{position{1, 2}, position{1, 5}}},
+			}},
+		},
+		{
+			desc:  "comment not adjacent",
+			input: "<#> a <#><div/>\n<!a><div>{children} \\{x}</><!/>",
+			output: []prefab{{
+				name: "a",
+				loc:  location{"u", textRange{position{1, 2}, position{1, 3}}},
+			}},
+		},
+		{
+			desc:  "placeholder outside prefab",
+			input: "<div a={x}/><!b><!/>",
+			output: []prefab{{
+				name: "b",
+				loc:  location{"u", textRange{position{0, 14}, position{0, 15}}},
+			}},
+		},
+		{
+			desc:  "unterminated comment",
+			input: "<!a><!/><#> b",
+			output: []prefab{{
+				name: "a",
+				loc:  location{"u", textRange{position{0, 2}, position{0, 3}}},
+			}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			core.TestEqual(t, indexPrefabs("u", tC.input), tC.output)
+		})
+	}
+}
+
+func TestCursorContext(t *testing.T) {
+	testCases := []struct {
+		desc, input string
+		output      context
+	}{
+		{
+			desc:   "element",
+			input:  "<div/>\n<bt|",
+			output: context{element: true, prefix: "bt"},
+		},
+		{
+			desc:   "empty element",
+			input:  "<|",
+			output: context{element: true},
+		},
+		{
+			desc:   "attribute",
+			input:  `<btn a="x" la|`,
+			output: context{tag: "btn", attribute: true, prefix: "la"},
+		},
+		{
+			desc:   "after newline",
+			input:  "<btn\n|",
+			output: context{tag: "btn", attribute: true},
+		},
+		{
+			desc:   "value",
+			input:  `<btn a="x|`,
+			output: context{},
+		},
+		{
+			desc:   "after equals",
+			input:  `<btn a=|`,
+			output: context{tag: "btn"},
+		},
+		{
+			desc:   "text",
+			input:  `<div>hello|`,
+			output: context{},
+		},
+		{
+			desc:   "comment",
+			input:  `<#> <d|`,
+			output: context{},
+		},
+		{
+			desc:   "closed comment",
+			input:  `<#> <d <#><d|`,
+			output: context{element: true, prefix: "d"},
+		},
+		{
+			desc:   "prefab definition",
+			input:  `<!ca|`,
+			output: context{},
+		},
+		{
+			desc:   "import",
+			input:  `<@import "a|`,
+			output: context{},
+		},
+		{
+			desc:   "escaped",
+			input:  `\<d|`,
+			output: context{},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			offset := len(tC.input) - 1
+			core.TestEqual(t, cursorContext(tC.input[:offset], offset), tC.output)
+		})
+	}
+}
+
+func TestPositions(t *testing.T) {
+	src := "a\nпри𝄞x\n"
+	testCases := []struct {
+		desc   string
+		pos    position
+		offset int
+	}{
+		{"start", position{0, 0}, 0},
+		{"second line", position{1, 0}, 2},
+		{"cyrillic", position{1, 2}, 6},
+		{"after surrogate pair", position{1, 5}, 12},
+		{"end of line", position{1, 6}, 13},
+		{"last line", position{2, 0}, 14},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if offset := toOffset(src, tC.pos); offset != tC.offset {
+				t.Error(offset)
+			}
+			core.TestEqual(t, toPosition(src, tC.offset), tC.pos)
+		})
+	}
+
+	outOfRange := []struct {
+		desc   string
+		pos    position
+		offset int
+	}{
+		{"character past line end", position{0, 10}, 1},
+		{"inside surrogate pair", position{1, 4}, 8},
+		{"line past end", position{5, 0}, len(src)},
+	}
+	for _, tC := range outOfRange {
+		t.Run(tC.desc, func(t *testing.T) {
+			if offset := toOffset(src, tC.pos); offset != tC.offset {
+				t.Error(offset)
+			}
+		})
+	}
+	core.TestEqual(t, toPosition(src, 100), position{2, 0})
+}
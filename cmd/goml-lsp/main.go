@@ -0,0 +1,48 @@
+// Command goml-lsp is language server for goml and goss files.
+//
+// Usage:
+//
+//	goml-lsp [flags]
+//
+// Server speaks language server protocol over standard input and output. It
+// publishes parse errors of open documents, completes element names from
+// definitions and prefabs and prefab parameters from their placeholders, shows
+// comment preceding prefab definition on hover and jumps to prefab definitions.
+// Prefabs are collected from all .goml files in workspace, imports are resolved
+// relative to the workspace root. Flags are:
+//
+//	-defs  comma separated list of element names the parser accepts
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var defs = flag.String("defs", "div", "comma separated list of element names the parser accepts")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: goml-lsp [flags]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var names []string
+	for _, name := range strings.Split(*defs, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	clean, err := newServer(os.Stdin, os.Stdout, names).run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "goml-lsp:", err)
+		os.Exit(2)
+	}
+	if !clean {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jakubDoka/goml"
+	"github.com/jakubDoka/goml/goss"
+)
+
+// server holds state of language server
+type server struct {
+	conn *conn
+	defs []string
+	root string
+	// docs contains text of open documents
+	docs map[string]string
+	// prefabs contains prefabs of open documents and goml files in workspace
+	prefabs  map[string][]prefab
+	shutdown bool
+}
+
+func newServer(r io.Reader, w io.Writer, defs []string) *server {
+	return &server{
+		conn:    newConn(r, w),
+		defs:    defs,
+		docs:    map[string]string{},
+		prefabs: map[string][]prefab{},
+	}
+}
+
+// run serves requests until exit notification or end of input, returned
+// boolean reports whether client shut the server down properly
+func (s *server) run() (bool, error) {
+	for {
+		r, rpcErr, err := s.conn.read()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if rpcErr != nil {
+			if err := s.conn.write(response{JSONRPC: "2.0", Error: rpcErr}); err != nil {
+				return false, err
+			}
+			continue
+		}
+		if r.Method == "exit" {
+			return s.shutdown, nil
+		}
+
+		result, rpcErr := s.handle(r)
+		if r.ID == nil {
+			continue
+		}
+		if err := s.conn.write(response{"2.0", r.ID, result, rpcErr}); err != nil {
+			return false, err
+		}
+	}
+}
+
+// handle dispatches request to its handler
+func (s *server) handle(r *request) (interface{}, *rpcError) {
+	switch r.Method {
+	case "initialize":
+		var params initializeParams
+		if err := decode(r, &params); err != nil {
+			return nil, err
+		}
+		s.initialize(params)
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":   1, // full document is sent on change
+				"completionProvider": map[string]interface{}{"triggerCharacters": []string{"<", " "}},
+				"hoverProvider":      true,
+				"definitionProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "goml-lsp"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := decode(r, &params); err != nil {
+			return nil, err
+		}
+		s.update(params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := decode(r, &params); err != nil {
+			return nil, err
+		}
+		if changes := params.ContentChanges; len(changes) != 0 {
+			s.update(params.TextDocument.URI, changes[len(changes)-1].Text)
+		}
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := decode(r, &params); err != nil {
+			return nil, err
+		}
+		s.close(params.TextDocument.URI)
+	case "textDocument/completion", "textDocument/hover", "textDocument/definition":
+		var params positionParams
+		if err := decode(r, &params); err != nil {
+			return nil, err
+		}
+		switch r.Method {
+		case "textDocument/completion":
+			return s.completion(params), nil
+		case "textDocument/hover":
+			return s.hover(params), nil
+		default:
+			return s.definition(params), nil
+		}
+	default:
+		if r.ID != nil {
+			return nil, &rpcError{codeMethodNotFound, "method not supported: " + r.Method}
+		}
+	}
+	return nil, nil
+}
+
+// decode decodes request parameters into v
+func decode(r *request, v interface{}) *rpcError {
+	if err := json.Unmarshal(r.Params, v); err != nil {
+		return &rpcError{codeInvalidParams, err.Error()}
+	}
+	return nil
+}
+
+// initialize indexes prefabs of all goml files in workspace
+func (s *server) initialize(params initializeParams) {
+	s.root = uriPath(params.RootURI)
+	if s.root == "" {
+		return
+	}
+	filepath.Walk(s.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() && path != s.root && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && filepath.Ext(path) == ".goml" {
+			if src, err := ioutil.ReadFile(path); err == nil {
+				uri := pathURI(path)
+				s.prefabs[uri] = indexPrefabs(uri, string(src))
+			}
+		}
+		return nil
+	})
+}
+
+// update stores new text of document and publishes its diagnostics
+func (s *server) update(uri, text string) {
+	s.docs[uri] = text
+
+	var diagnostics []diagnostic
+	if strings.HasSuffix(uri, ".goss") {
+		diagnostics = gossDiagnostics(text)
+	} else {
+		s.prefabs[uri] = indexPrefabs(uri, text)
+		diagnostics = s.gomlDiagnostics(uri, text)
+	}
+	s.publish(uri, diagnostics)
+}
+
+// close forgets document text, prefabs are indexed from disk again
+func (s *server) close(uri string) {
+	delete(s.docs, uri)
+	delete(s.prefabs, uri)
+	if src, err := ioutil.ReadFile(uriPath(uri)); err == nil && strings.HasSuffix(uri, ".goml") {
+		s.prefabs[uri] = indexPrefabs(uri, string(src))
+	}
+	s.publish(uri, nil)
+}
+
+func (s *server) publish(uri string, diagnostics []diagnostic) {
+	if diagnostics == nil {
+		diagnostics = []diagnostic{}
+	}
+	s.conn.write(notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  publishDiagnosticsParams{uri, diagnostics},
+	})
+}
+
+// gomlDiagnostics parses goml document, imports are read from disk relative to
+// the document
+func (s *server) gomlDiagnostics(uri, text string) (diagnostics []diagnostic) {
+	p := goml.NParser(&goss.Parser{})
+	p.AddDefinitions(s.defs...)
+
+	var found []goml.Diagnostic
+	if rel, err := filepath.Rel(s.root, uriPath(uri)); s.root != "" && err == nil && !strings.HasPrefix(rel, "..") {
+		p.SetFS(os.DirFS(s.root))
+		_, found = p.ParseRecoverFile(filepath.ToSlash(rel), []byte(text))
+	} else {
+		_, found = p.ParseRecover([]byte(text))
+	}
+
+	for _, d := range found {
+		offset, err := d.Pos.Offset, d.Err
+		var pe *goml.ParseError
+		if errors.As(err, &pe) {
+			// position is shown by editor so only kind is in the message
+			offset, err = pe.Offset, pe.Kind
+		}
+		diagnostics = append(diagnostics, newDiagnostic("goml", text, offset, err))
+	}
+	return
+}
+
+func gossDiagnostics(text string) []diagnostic {
+	p := goss.Parser{}
+	if _, err := p.Parse([]byte(text)); err != nil {
+		if p.ErrKind != nil {
+			err = p.ErrKind
+		}
+		return []diagnostic{newDiagnostic("goss", text, p.ErrPos.Offset, err)}
+	}
+	return nil
+}
+
+// newDiagnostic creates error diagnostic that spans identifier at offset or
+// one character if there is none
+func newDiagnostic(source, text string, offset int, err error) diagnostic {
+	if offset > len(text) {
+		offset = len(text)
+	}
+	_, start, end := identAt(text, offset)
+	if start == end {
+		start, end = offset, offset+1
+		if end > len(text) {
+			end = len(text)
+		}
+	}
+	return diagnostic{
+		Range:    span(text, start, end),
+		Severity: 1,
+		Source:   source,
+		Message:  err.Error(),
+	}
+}
+
+// completion completes element names in element head and prefab parameters
+// in place of attribute
+func (s *server) completion(pos positionParams) []completionItem {
+	items := []completionItem{}
+	text, ok := s.docs[pos.TextDocument.URI]
+	if !ok {
+		return items
+	}
+	ctx := cursorContext(text, toOffset(text, pos.Position))
+
+	switch {
+	case ctx.element:
+		for _, d := range s.defs {
+			if strings.HasPrefix(d, ctx.prefix) {
+				items = append(items, completionItem{Label: d, Kind: kindClass, Detail: "definition"})
+			}
+		}
+		seen := map[string]bool{}
+		for _, p := range s.allPrefabs(pos.TextDocument.URI) {
+			if !seen[p.name] && strings.HasPrefix(p.name, ctx.prefix) {
+				seen[p.name] = true
+				items = append(items, completionItem{p.name, kindClass, p.detail(), p.doc})
+			}
+		}
+	case ctx.attribute:
+		p, ok := s.lookup(pos.TextDocument.URI, ctx.tag)
+		if !ok {
+			break
+		}
+		for _, param := range p.params {
+			if strings.HasPrefix(param, ctx.prefix) {
+				items = append(items, completionItem{Label: param, Kind: kindField, Detail: "parameter of " + p.name})
+			}
+		}
+	}
+	return items
+}
+
+// hover shows usage and documentation of prefab under cursor
+func (s *server) hover(pos positionParams) *hover {
+	p, text, start, end, ok := s.prefabAt(pos)
+	if !ok {
+		return nil
+	}
+	value := "```goml\n" + p.detail() + "\n```"
+	if p.doc != "" {
+		value += "\n\n" + p.doc
+	}
+	return &hover{markupContent{"markdown", value}, span(text, start, end)}
+}
+
+// definition returns location of prefab definition under cursor
+func (s *server) definition(pos positionParams) *location {
+	p, _, _, _, ok := s.prefabAt(pos)
+	if !ok {
+		return nil
+	}
+	return &p.loc
+}
+
+// prefabAt finds prefab whose name is under cursor in element name
+func (s *server) prefabAt(pos positionParams) (p prefab, text string, start, end int, ok bool) {
+	text, ok = s.docs[pos.TextDocument.URI]
+	if !ok {
+		return
+	}
+	name, start, end := identAt(text, toOffset(text, pos.Position))
+	if name == "" || !strings.HasSuffix(text[:start], "<") && !strings.HasSuffix(text[:start], "<!") {
+		return p, text, start, end, false
+	}
+	p, ok = s.lookup(pos.TextDocument.URI, name)
+	return p, text, start, end, ok
+}
+
+// lookup finds prefab by name, definitions in document uri are preferred
+func (s *server) lookup(uri, name string) (prefab, bool) {
+	for _, p := range s.allPrefabs(uri) {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return prefab{}, false
+}
+
+// allPrefabs returns prefabs of document uri followed by prefabs from other
+// files in stable order
+func (s *server) allPrefabs(uri string) []prefab {
+	uris := make([]string, 0, len(s.prefabs))
+	for u := range s.prefabs {
+		if u != uri {
+			uris = append(uris, u)
+		}
+	}
+	sort.Strings(uris)
+
+	all := append([]prefab(nil), s.prefabs[uri]...)
+	for _, u := range uris {
+		all = append(all, s.prefabs[u]...)
+	}
+	return all
+}
+
+// uriPath converts file uri into path, it returns empty string for other schemes
+func uriPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// pathURI converts path into file uri
+func pathURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
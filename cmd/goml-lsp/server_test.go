@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jakubDoka/goml"
+	"github.com/jakubDoka/goml/core"
+)
+
+func TestSession(t *testing.T) {
+	root := t.TempDir()
+	lib := "<#> Clickable button <#>\n<!btn><div text={label} on={click=\"x\"}/><!/>\n"
+	if err := ioutil.WriteFile(filepath.Join(root, "lib.goml"), []byte(lib), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rootURI, libURI, mainURI := pathURI(root), pathURI(filepath.Join(root, "lib.goml")), pathURI(filepath.Join(root, "main.goml"))
+
+	doc := func(pos position) map[string]interface{} {
+		return map[string]interface{}{"textDocument": map[string]string{"uri": mainURI}, "position": pos}
+	}
+	var in bytes.Buffer
+	client := newConn(nil, &in)
+	send := func(id int, method string, params interface{}) {
+		m := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+		if id != 0 {
+			m["id"] = id
+		}
+		if err := client.write(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	send(1, "initialize", map[string]string{"rootUri": rootURI})
+	send(0, "textDocument/didOpen", map[string]interface{}{"textDocument": map[string]string{
+		"uri":  mainURI,
+		"text": "<@import \"lib.goml\">\n<btn label=\"a\"/>\n<spam/>\n<",
+	}})
+	send(2, "textDocument/completion", doc(position{1, 3}))
+	send(3, "textDocument/completion", doc(position{1, 5}))
+	send(4, "textDocument/hover", doc(position{1, 2}))
+	send(5, "textDocument/definition", doc(position{1, 2}))
+	send(6, "textDocument/definition", doc(position{2, 2}))
+	send(7, "unknown", nil)
+	send(8, "shutdown", nil)
+	send(0, "exit", nil)
+
+	var out bytes.Buffer
+	done := make(chan struct{})
+	var (
+		clean bool
+		err   error
+	)
+	go func() {
+		clean, err = newServer(&in, &out, []string{"div"}).run()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not finish")
+	}
+	if !clean || err != nil {
+		t.Error(clean, err)
+	}
+
+	var messages []map[string]interface{}
+	reader := newConn(&out, nil)
+	for {
+		header, err := reader.r.ReadMIMEHeader()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var length int
+		json.Unmarshal([]byte(header.Get("Content-Length")), &length)
+		body := make([]byte, length)
+		io.ReadFull(reader.r.R, body)
+		var m map[string]interface{}
+		if err := json.Unmarshal(body, &m); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, m)
+	}
+	if len(messages) != 9 {
+		t.Fatal(len(messages), messages)
+	}
+
+	// compares value with expected value through json
+	expect := func(i int, key string, expected interface{}) {
+		t.Helper()
+		data, _ := json.Marshal(expected)
+		var e interface{}
+		json.Unmarshal(data, &e)
+		core.TestEqual(t, messages[i][key], e)
+	}
+
+	if messages[0]["result"] == nil {
+		t.Error(messages[0])
+	}
+	expect(1, "params", publishDiagnosticsParams{mainURI, []diagnostic{
+		{textRange{position{2, 1}, position{2, 5}}, 1, "goml", goml.ErrUnknown.Error()},
+		{textRange{position{3, 0}, position{3, 1}}, 1, "goml", goml.ErrDiv.Incomplete.Error()},
+	}})
+	expect(2, "result", []completionItem{
+		{"btn", kindClass, `<btn label="" click=""/>`, "Clickable button"},
+	})
+	expect(3, "result", []completionItem{
+		{Label: "label", Kind: kindField, Detail: "parameter of btn"},
+		{Label: "click", Kind: kindField, Detail: "parameter of btn"},
+	})
+	expect(4, "result", hover{
+		markupContent{"markdown", "```goml\n<btn label=\"\" click=\"\"/>\n```\n\nClickable button"},
+		textRange{position{1, 1}, position{1, 4}},
+	})
+	expect(5, "result", location{libURI, textRange{position{1, 2}, position{1, 5}}})
+	expect(6, "result", nil)
+	expect(7, "error", rpcError{codeMethodNotFound, "method not supported: unknown"})
+	expect(8, "result", nil)
+}
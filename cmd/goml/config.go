@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jakubDoka/goml"
+	"github.com/jakubDoka/goml/goss"
+)
+
+// config is loaded from json file, for example:
+//
+//	{
+//		"definitions": ["div", "span", "button"],
+//		"prefabs": ["ui/widgets.goml"],
+//		"root": "."
+//	}
+type config struct {
+	// Definitions are names of elements the parser accepts
+	Definitions []string `json:"definitions"`
+	// Prefabs are files parsed before every checked file, their prefabs
+	// can be used without import
+	Prefabs []string `json:"prefabs"`
+	// Root is directory imports and prefab files are resolved from, it is relative
+	// to config file and defaults to its directory
+	Root string `json:"root"`
+}
+
+// loadConfig reads config from path
+func loadConfig(path string) (*config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	c.Root = filepath.Join(filepath.Dir(path), c.Root)
+	return &c, nil
+}
+
+// parser creates parser with definitions and prefabs from config
+func (c *config) parser() (*goml.Parser, error) {
+	p := goml.NParser(&goss.Parser{})
+	p.AddDefinitions(c.Definitions...)
+	p.SetFS(os.DirFS(c.Root))
+	for _, name := range c.Prefabs {
+		if _, err := p.ParseFile(filepath.ToSlash(name)); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// name converts path to name of file relative to c.Root
+func (c *config) name(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	root, err := filepath.Abs(c.Root)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s: file is outside of root %s", path, c.Root)
+	}
+	return filepath.ToSlash(rel), nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jakubDoka/goml/core"
+)
+
+// writeFiles creates files with given content in dir, names use slashes
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"full.json":    `{"definitions": ["div"], "prefabs": ["lib.goml"], "root": "ui"}`,
+		"empty.json":   `{}`,
+		"invalid.json": `{"definitions": "div"}`,
+	})
+
+	testCases := []struct {
+		desc, file string
+		output     *config
+		err        bool
+	}{
+		{
+			desc:   "full",
+			file:   "full.json",
+			output: &config{[]string{"div"}, []string{"lib.goml"}, filepath.Join(dir, "ui")},
+		},
+		{
+			desc:   "root defaults to config directory",
+			file:   "empty.json",
+			output: &config{Root: dir},
+		},
+		{
+			desc: "invalid",
+			file: "invalid.json",
+			err:  true,
+		},
+		{
+			desc: "missing",
+			file: "missing.json",
+			err:  true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			c, err := loadConfig(filepath.Join(dir, tC.file))
+			if (err != nil) != tC.err {
+				t.Error(err)
+				return
+			}
+			core.TestEqual(t, c, tC.output)
+		})
+	}
+}
+
+func TestConfigName(t *testing.T) {
+	dir := t.TempDir()
+	c := config{Root: filepath.Join(dir, "ui")}
+
+	testCases := []struct {
+		desc, path, output string
+		err                bool
+	}{
+		{
+			desc:   "top level",
+			path:   filepath.Join(dir, "ui", "main.goml"),
+			output: "main.goml",
+		},
+		{
+			desc:   "nested",
+			path:   filepath.Join(dir, "ui", "lib", "..", "widgets", "card.goml"),
+			output: "widgets/card.goml",
+		},
+		{
+			desc: "outside",
+			path: filepath.Join(dir, "main.goml"),
+			err:  true,
+		},
+		{
+			desc: "sibling with common prefix",
+			path: filepath.Join(dir, "ui2", "main.goml"),
+			err:  true,
+		},
+		{
+			desc: "root parent",
+			path: dir,
+			err:  true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			name, err := c.name(tC.path)
+			if (err != nil) != tC.err {
+				t.Error(err)
+				return
+			}
+			core.TestEqual(t, name, tC.output)
+		})
+	}
+}
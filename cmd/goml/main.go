@@ -0,0 +1,301 @@
+// Command goml parses, checks and inspects goml files.
+//
+// Usage:
+//
+//	goml [-config file] command [arguments]
+//
+// Commands are:
+//
+//	check [path ...]      parse files and report errors, directories are walked
+//	                      and all .goml files in them are checked, files parsed
+//	                      with config prefabs are checked only once
+//	dump [-json] file     print tree with expanded prefabs as goml or json
+//	prefabs [path ...]    list prefabs from config and given files with parameters
+//	gen [flags] file ...  generate Go file with function for each file that returns
+//	                      its tree, see below
+//
+// Definitions and shared prefabs are read from json config file, goml.json by
+// default:
+//
+//	{
+//		"definitions": ["div", "span", "button"],
+//		"prefabs": ["ui/widgets.goml"],
+//		"root": "."
+//	}
+//
+// Imports and files listed in prefabs are resolved relative to root, which is
+// relative to config file.
+//
+// Gen is meant to be used with go:generate, errors in layouts then fail the
+// generation and binary does not need to parse them at runtime:
+//
+//	//go:generate goml gen -o layouts_goml.go main.goml ui/user-card.goml
+//
+// generates functions Main and UserCard returning goml.Element. Flags of gen are:
+//
+//	-o      output file, standard output is used if empty
+//	-pkg    package name, defaults to $GOPACKAGE set by go generate
+//	-spans  include source spans of elements
+//
+// Generated trees differ from parsed ones in two ways: spans are zero unless
+// -spans is given, and elements created from prefabs lose prefab parameter data,
+// so their json has no "params" and Printer cannot restore prefab definitions.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jakubDoka/goml"
+	"github.com/jakubDoka/goml/render/gocode"
+)
+
+var configPath = flag.String("config", "goml.json", "path to config file")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: goml [-config file] check|dump|prefabs|gen [arguments]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	c, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "goml:", err)
+		os.Exit(2)
+	}
+
+	cmd := &command{config: c, stdout: os.Stdout, stderr: os.Stderr}
+	args := flag.Args()[1:]
+	switch name := flag.Arg(0); name {
+	case "check":
+		cmd.check(args)
+	case "dump":
+		cmd.dump(args)
+	case "prefabs":
+		cmd.prefabs(args)
+	case "gen":
+		cmd.gen(args)
+	default:
+		fmt.Fprintf(os.Stderr, "goml: unknown command %q\n", name)
+		flag.Usage()
+		os.Exit(2)
+	}
+	if cmd.failed {
+		os.Exit(1)
+	}
+}
+
+// command runs subcommands with config, output goes to stdout and errors
+// to stderr
+type command struct {
+	*config
+	stdout, stderr io.Writer
+	// failed is set when any error is reported
+	failed bool
+}
+
+// check parses each file separately so prefabs do not leak between files
+func (c *command) check(paths []string) {
+	p, err := c.parser()
+	if err != nil {
+		c.report(err)
+		return
+	}
+	r := p.Freeze()
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			c.report(err)
+			continue
+		}
+		if !info.IsDir() {
+			c.report(c.checkFile(r, path))
+			continue
+		}
+		c.report(filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && filepath.Ext(path) == ".goml" {
+				c.report(c.checkFile(r, path))
+			}
+			return nil
+		}))
+	}
+}
+
+func (c *command) checkFile(r *goml.Registry, path string) error {
+	name, err := c.name(path)
+	if err != nil {
+		return err
+	}
+	if r.Imported(name) {
+		// already parsed with config prefabs or imported by them
+		return nil
+	}
+	_, err = r.ParseFile(name)
+	return err
+}
+
+func (c *command) dump(args []string) {
+	fs := flag.NewFlagSet("dump", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print tree as json")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(c.stderr, "usage: goml dump [-json] file")
+		os.Exit(2)
+	}
+
+	name, err := c.name(fs.Arg(0))
+	if err != nil {
+		c.report(err)
+		return
+	}
+	p, err := c.parser()
+	if err == nil && p.Freeze().Imported(name) {
+		// file is parsed on its own so its prefabs are not defined twice
+		own := *c.config
+		own.Prefabs = nil
+		p, err = own.parser()
+	}
+	if err != nil {
+		c.report(err)
+		return
+	}
+	root, err := p.ParseFile(name)
+	if err != nil {
+		c.report(err)
+		return
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(root, "", "\t")
+		if err != nil {
+			c.report(err)
+			return
+		}
+		_, err = c.stdout.Write(append(data, '\n'))
+		c.report(err)
+		return
+	}
+	c.report(goml.Print(c.stdout, root))
+}
+
+// prefabs lists prefabs one per line followed by parameters in placeholder
+// syntax, for example:
+//
+//	button action! label="OK"
+func (c *command) prefabs(paths []string) {
+	p, err := c.parser()
+	if err != nil {
+		c.report(err)
+		return
+	}
+	r := p.Freeze()
+	for _, path := range paths {
+		name, err := c.name(path)
+		if err == nil && !r.Imported(name) {
+			_, err = p.ParseFile(name)
+		}
+		if err != nil {
+			c.report(err)
+			return
+		}
+	}
+
+	for _, name := range p.Prefabs() {
+		info, _ := p.Prefab(name)
+		fmt.Fprint(c.stdout, name)
+		for _, param := range info.Params {
+			fmt.Fprint(c.stdout, " ", param.Name)
+			switch {
+			case param.Required:
+				fmt.Fprint(c.stdout, "!")
+			case param.HasDefault:
+				fmt.Fprintf(c.stdout, "=%q", param.Default)
+			}
+		}
+		fmt.Fprintln(c.stdout)
+	}
+}
+
+func (c *command) gen(args []string) {
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	out := fs.String("o", "", "output file, standard output is used if empty")
+	pkg := fs.String("pkg", os.Getenv("GOPACKAGE"), "package name")
+	spans := fs.Bool("spans", false, "include source spans of elements")
+	fs.Parse(args)
+	if fs.NArg() == 0 || *pkg == "" {
+		fmt.Fprintln(c.stderr, "usage: goml gen [-o file] [-pkg name] [-spans] file ...")
+		os.Exit(2)
+	}
+
+	p, err := c.parser()
+	if err != nil {
+		c.report(err)
+		return
+	}
+	r := p.Freeze()
+
+	var funcs []gocode.Func
+	for _, path := range fs.Args() {
+		name, err := c.name(path)
+		if err != nil {
+			c.report(err)
+			continue
+		}
+		root, err := r.ParseFile(name)
+		if err != nil {
+			c.report(err)
+			continue
+		}
+		fn := gocode.FuncName(name)
+		funcs = append(funcs, gocode.Func{
+			Name: fn,
+			Doc:  fn + " returns tree parsed from " + name,
+			Root: root,
+		})
+	}
+	if c.failed {
+		return
+	}
+
+	g := gocode.Generator{Package: *pkg, Spans: *spans, Source: strings.Join(fs.Args(), ", ")}
+	src, err := g.Generate(funcs...)
+	if err != nil {
+		c.report(err)
+		return
+	}
+	if *out == "" {
+		_, err = c.stdout.Write(src)
+	} else {
+		err = ioutil.WriteFile(*out, src, 0644)
+	}
+	c.report(err)
+}
+
+// report prints error, parse errors are followed by excerpt of source
+func (c *command) report(err error) {
+	if err == nil {
+		return
+	}
+	c.failed = true
+	fmt.Fprintln(c.stderr, err)
+	var pe *goml.ParseError
+	if errors.As(err, &pe) {
+		fmt.Fprint(c.stderr, pe.Excerpt())
+	}
+}
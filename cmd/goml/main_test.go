@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/jakubDoka/goml"
+	"github.com/jakubDoka/goml/core"
+)
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"goml.json":           `{"definitions": ["div", "span"], "prefabs": ["lib/widgets.goml"], "root": "ui"}`,
+		"ui/lib/widgets.goml": `<@import "base.goml"><!card><div title={title} size={size="m"}>{children}</><!/>`,
+		"ui/lib/base.goml":    `<!base><span/><!/>`,
+		"ui/main.goml":        `<card title="a"><base/></>`,
+		"ui/extra.goml":       `<!badge><span text={text}/><!/>`,
+		"ui/bad/broken.goml":  "<div>\n<spam/></>",
+		"outside.goml":        `<div/>`,
+	})
+	c, err := loadConfig(filepath.Join(dir, "goml.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := func(name string) string {
+		return filepath.Join(dir, filepath.FromSlash(name))
+	}
+
+	testCases := []struct {
+		desc           string
+		run            func(c *command)
+		stdout, stderr string
+		failed         bool
+	}{
+		{
+			desc: "check skips files imported by config prefabs",
+			run:  func(c *command) { c.check([]string{path("ui/lib"), path("ui/main.goml"), path("ui/extra.goml")}) },
+		},
+		{
+			desc:   "check walks directory",
+			run:    func(c *command) { c.check([]string{path("ui")}) },
+			stderr: "bad/broken.goml:2:6: " + goml.ErrUnknown.Error() + "\n<spam/></>\n     ^\n",
+			failed: true,
+		},
+		{
+			desc:   "check outside of root",
+			run:    func(c *command) { c.check([]string{path("outside.goml")}) },
+			stderr: path("outside.goml") + ": file is outside of root " + path("ui") + "\n",
+			failed: true,
+		},
+		{
+			desc:   "dump",
+			run:    func(c *command) { c.dump([]string{path("ui/main.goml")}) },
+			stdout: "<div size=\"m\" title=\"a\">\n\t<span/>\n</>\n",
+		},
+		{
+			desc: "dump json",
+			run:  func(c *command) { c.dump([]string{"-json", path("ui/lib/base.goml")}) },
+			stdout: `{
+	"name": "",
+	"span": {
+		"start": {
+			"offset": 0,
+			"line": 0,
+			"column": 0
+		},
+		"end": {
+			"offset": 18,
+			"line": 0,
+			"column": 18
+		}
+	}
+}
+`,
+		},
+		{
+			desc:   "prefabs",
+			run:    func(c *command) { c.prefabs([]string{path("ui/extra.goml"), path("ui/lib/widgets.goml")}) },
+			stdout: "badge text\nbase\ncard size=\"m\" title\n",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			cmd := &command{config: c, stdout: &stdout, stderr: &stderr}
+			tC.run(cmd)
+			core.TestEqual(t, stdout.String(), tC.stdout)
+			core.TestEqual(t, stderr.String(), tC.stderr)
+			core.TestEqual(t, cmd.failed, tC.failed)
+		})
+	}
+}
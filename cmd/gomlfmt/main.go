@@ -0,0 +1,180 @@
+// Command gomlfmt formats goml and goss files.
+//
+// Usage:
+//
+//	gomlfmt [flags] [path ...]
+//
+// Without paths it formats goml from standard input. Directories are walked
+// and all .goml and .goss files in them are formatted. Flags are:
+//
+//	-l  list files whose formatting differs from gomlfmt's
+//	-d  display diffs instead of rewriting files
+//	-w  write result to source file instead of standard output
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/jakubDoka/goml/format"
+)
+
+var (
+	list  = flag.Bool("l", false, "list files whose formatting differs from gomlfmt's")
+	diff  = flag.Bool("d", false, "display diffs instead of rewriting files")
+	write = flag.Bool("w", false, "write result to source file instead of stdout")
+)
+
+var exitCode int
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: gomlfmt [flags] [path ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(os.Stderr, "gomlfmt: cannot use -w with standard input")
+			os.Exit(2)
+		}
+		src, err := ioutil.ReadAll(os.Stdin)
+		if err == nil {
+			err = process("<standard input>", src, format.Goml)
+		}
+		report(err)
+		os.Exit(exitCode)
+	}
+
+	for _, path := range flag.Args() {
+		info, err := os.Stat(path)
+		if err != nil {
+			report(err)
+			continue
+		}
+		if !info.IsDir() {
+			report(processFile(path))
+			continue
+		}
+		report(filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && formatter(path) != nil {
+				report(processFile(path))
+			}
+			return nil
+		}))
+	}
+	os.Exit(exitCode)
+}
+
+// formatter returns formatter for file based on extension
+func formatter(path string) func([]byte) ([]byte, error) {
+	switch filepath.Ext(path) {
+	case ".goml":
+		return format.Goml
+	case ".goss":
+		return format.Goss
+	}
+	return nil
+}
+
+func processFile(path string) error {
+	f := formatter(path)
+	if f == nil {
+		return fmt.Errorf("%s: unknown file extension, expected .goml or .goss", path)
+	}
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return process(path, src, f)
+}
+
+func process(path string, src []byte, f func([]byte) ([]byte, error)) error {
+	res, err := f(src)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	if !*list && !*diff && !*write {
+		_, err = os.Stdout.Write(res)
+		return err
+	}
+
+	if bytes.Equal(src, res) {
+		return nil
+	}
+
+	if *list {
+		fmt.Println(path)
+	}
+	if *write {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, res, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if *diff {
+		d, err := diffBytes(path, src, res)
+		if err != nil {
+			return fmt.Errorf("computing diff: %v", err)
+		}
+		os.Stdout.Write(d)
+	}
+	return nil
+}
+
+// diffBytes runs diff -u on original and formatted source
+func diffBytes(path string, a, b []byte) ([]byte, error) {
+	fa, err := writeTemp(a)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(fa)
+	fb, err := writeTemp(b)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(fb)
+
+	out, err := exec.Command("diff", "-u", "--label", path+".orig", "--label", path, fa, fb).Output()
+	if len(out) != 0 {
+		// diff exits with 1 when files differ
+		err = nil
+	}
+	return out, err
+}
+
+func writeTemp(data []byte) (string, error) {
+	f, err := ioutil.TempFile("", "gomlfmt")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(data)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func report(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 2
+	}
+}
@@ -0,0 +1,88 @@
+// Command html2goml converts html fragments into goml.
+//
+// Usage:
+//
+//	html2goml [flags] [path ...]
+//
+// Without paths it converts html from standard input. Result is written to standard
+// output and everything that could not be converted exactly is reported to standard
+// error as path:line:column: message, line and column are 1-based and column
+// counts characters. Flags are:
+//
+//	-w  write result next to source file with .goml extension
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jakubDoka/goml"
+	"github.com/jakubDoka/goml/render/html"
+)
+
+var write = flag.Bool("w", false, "write result next to source file with .goml extension")
+
+var exitCode int
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: html2goml [flags] [path ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(os.Stderr, "html2goml: cannot use -w with standard input")
+			os.Exit(2)
+		}
+		src, err := ioutil.ReadAll(os.Stdin)
+		if err == nil {
+			err = convert("<standard input>", src)
+		}
+		report(err)
+		os.Exit(exitCode)
+	}
+
+	for _, path := range flag.Args() {
+		src, err := ioutil.ReadFile(path)
+		if err == nil {
+			err = convert(path, src)
+		}
+		report(err)
+	}
+	os.Exit(exitCode)
+}
+
+func convert(path string, src []byte) error {
+	root, diagnostics := html.Import(src)
+	for _, d := range diagnostics {
+		pe := d.Err.(*goml.ParseError)
+		pe.File = path
+		fmt.Fprintln(os.Stderr, pe)
+	}
+
+	var buff bytes.Buffer
+	if err := goml.Print(&buff, root); err != nil {
+		return err
+	}
+
+	if !*write {
+		_, err := os.Stdout.Write(buff.Bytes())
+		return err
+	}
+	dst := strings.TrimSuffix(path, filepath.Ext(path)) + ".goml"
+	return ioutil.WriteFile(dst, buff.Bytes(), 0644)
+}
+
+func report(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 2
+	}
+}
@@ -11,12 +11,30 @@ import (
 // ErrReport reports error location
 var ErrReport = sterr.New("located at %d:%d")
 
+// Pos is a position in source, Line and Column are counted from zero
+// and Column is in bytes, same as in error reports
+type Pos struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Span is a range of source, End is exclusive
+type Span struct {
+	Start Pos `json:"start"`
+	End   Pos `json:"end"`
+}
+
 // Parser serves base for a parser
 type Parser struct {
 	Source             []byte
 	I, Line, LineStart int
 	Ch                 byte
 	Err                error
+	// ErrPos is position where Err was raised and ErrKind is the raised error
+	// without position report
+	ErrPos  Pos
+	ErrKind error
 }
 
 // Restart restarts parser state for another parsing
@@ -26,6 +44,7 @@ func (p *Parser) Restart(Source []byte) {
 	p.Line = 0
 	p.LineStart = 0
 	p.Err = nil
+	p.ErrKind = nil
 }
 
 // Failed returns whether error happened
@@ -35,6 +54,8 @@ func (p *Parser) Failed() bool {
 
 // error sets p.Err and adds the Line info
 func (p *Parser) Error(err sterr.Err) {
+	p.ErrPos = p.Pos()
+	p.ErrKind = err
 	p.Err = err.Wrap(p.ReportError())
 }
 
@@ -43,12 +64,35 @@ func (p *Parser) ReportError() sterr.Err {
 	return ErrReport.Args(p.Line, p.I-p.LineStart)
 }
 
+// Pos returns position of p.Ch
+func (p *Parser) Pos() Pos {
+	return Pos{p.I, p.Line, p.I - p.LineStart}
+}
+
+// End returns position right after p.Ch, it is used to close spans
+func (p *Parser) End() Pos {
+	return Pos{p.I + 1, p.Line, p.I + 1 - p.LineStart}
+}
+
+// SourceEnd returns position right after the last byte of source, it assumes
+// that parser already reached the end
+func (p *Parser) SourceEnd() Pos {
+	return Pos{len(p.Source), p.Line, len(p.Source) - p.LineStart}
+}
+
+// NewLine updates line info, it should be called when p.Ch is '\n'
+// and is consumed by something else then p.SkipSpace
+func (p *Parser) NewLine() {
+	p.Line++
+	p.LineStart = p.I + 1
+}
+
 // CheckSlice checks if next len(lice) bytes are equal to slice content
 //
 // ok will be false if there is not enough bytes in p.Source
 // equal will be true if slices are equal
 func (p *Parser) CheckSlice(slice []byte) (equal, ok bool) {
-	if len(p.Source) <= len(slice)+p.I {
+	if len(p.Source) < len(slice)+p.I {
 		return
 	}
 
@@ -80,8 +124,7 @@ func (p *Parser) SkipSpace() bool {
 		switch p.Ch {
 		case ' ', '\t', '\r':
 		case '\n':
-			p.Line++
-			p.LineStart = p.I + 1
+			p.NewLine()
 		default:
 			return true
 		}
@@ -0,0 +1,380 @@
+package goml
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/jakubDoka/goml/goss"
+	"github.com/jakubDoka/sterr"
+)
+
+// ErrPatch stores errors returned by Apply
+var ErrPatch = struct {
+	Path, Index, Kind sterr.Err
+}{
+	sterr.New("path %v does not lead to any element"),
+	sterr.New("child index %d is out of range in element at %v"),
+	sterr.New("unknown operation kind %d"),
+}
+
+// KeyAttribute gives element stable identity when diffing, elements with same name
+// and key are considered the same element even if they move
+const KeyAttribute = "key"
+
+// OpKind is kind of patch operation
+type OpKind int
+
+// OpKind values
+const (
+	InsertChild OpKind = iota
+	RemoveChild
+	MoveChild
+	SetAttribute
+	RemoveAttribute
+	SetStyle
+	RemoveStyle
+)
+
+// String implements fmt.Stringer interface
+func (k OpKind) String() string {
+	switch k {
+	case InsertChild:
+		return "insert"
+	case RemoveChild:
+		return "remove"
+	case MoveChild:
+		return "move"
+	case SetAttribute:
+		return "set-attribute"
+	case RemoveAttribute:
+		return "remove-attribute"
+	case SetStyle:
+		return "set-style"
+	case RemoveStyle:
+		return "remove-style"
+	}
+	return "unknown"
+}
+
+// Op is one patch operation, Path holds child indexes leading from root to the
+// element operation modifies, for child operations it is the parent
+type Op struct {
+	Kind OpKind
+	Path []int
+	// Index is child index for InsertChild and RemoveChild and destination
+	// for MoveChild, destination is index after the child is removed from From
+	Index, From int
+	// Element is inserted by InsertChild
+	Element Element
+	// Name is attribute or style property name
+	Name string
+	// Values are set by SetAttribute
+	Values []string
+	// Style values are set by SetStyle
+	Style []interface{}
+}
+
+// Patch is list of operations, each operation indexes tree as it is after the
+// previous operation was applied
+type Patch []Op
+
+// Diff returns patch that turns old into new. Root elements are expected to have
+// the same name. Children are matched by name and KeyAttribute, unkeyed children
+// with same name are matched in order. Unmatched children are removed or inserted,
+// matched children are moved so that the least of them has to move and then diffed
+// recursively. Span and prefab data are ignored. Patch shares elements and values
+// with new.
+func Diff(old, new Element) Patch {
+	var patch Patch
+	patch.element(&old, &new, nil)
+	return patch
+}
+
+// element diffs two matched elements
+func (p *Patch) element(old, new *Element, path []int) {
+	p.attributes(old, new, path)
+	p.style(old, new, path)
+	p.children(old, new, path)
+}
+
+// attributes diffs attributes in sorted order
+func (p *Patch) attributes(old, new *Element, path []int) {
+	for _, k := range sortedKeys(old.Attributes, new.Attributes) {
+		ov, ook := old.Attributes[k]
+		nv, nok := new.Attributes[k]
+		switch {
+		case !nok:
+			p.add(Op{Kind: RemoveAttribute, Path: path, Name: k})
+		case !ook || !equalStrings(ov, nv):
+			p.add(Op{Kind: SetAttribute, Path: path, Name: k, Values: nv})
+		}
+	}
+}
+
+// style diffs style properties in sorted order
+func (p *Patch) style(old, new *Element, path []int) {
+	keys := make([]string, 0, len(old.Style)+len(new.Style))
+	for k := range old.Style {
+		keys = append(keys, k)
+	}
+	for k := range new.Style {
+		if _, ok := old.Style[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		ov, ook := old.Style[k]
+		nv, nok := new.Style[k]
+		switch {
+		case !nok:
+			p.add(Op{Kind: RemoveStyle, Path: path, Name: k})
+		case !ook || !reflect.DeepEqual(ov, nv):
+			p.add(Op{Kind: SetStyle, Path: path, Name: k, Style: nv})
+		}
+	}
+}
+
+// children reconciles children, removals go first, then moves and insertions
+// from the last child and then matched children are diffed
+func (p *Patch) children(old, new *Element, path []int) {
+	match := matchChildren(old.Children, new.Children)
+
+	// current holds new indexes of remaining old children in their current order
+	used := make([]bool, len(old.Children))
+	for _, o := range match {
+		if o != -1 {
+			used[o] = true
+		}
+	}
+	for i := len(old.Children) - 1; i >= 0; i-- {
+		if !used[i] {
+			p.add(Op{Kind: RemoveChild, Path: path, Index: i})
+		}
+	}
+	newIdx := make([]int, len(old.Children))
+	for n, o := range match {
+		if o != -1 {
+			newIdx[o] = n
+		}
+	}
+	current := make([]int, 0, len(new.Children))
+	for o := range old.Children {
+		if used[o] {
+			current = append(current, newIdx[o])
+		}
+	}
+
+	stay := longestIncreasing(current)
+	for n := len(new.Children) - 1; n >= 0; n-- {
+		if match[n] != -1 && stay[n] {
+			continue
+		}
+		// child is placed before its successor
+		anchor := len(current)
+		if n+1 < len(new.Children) {
+			anchor = indexOf(current, n+1)
+		}
+		if match[n] == -1 {
+			p.add(Op{Kind: InsertChild, Path: path, Index: anchor, Element: new.Children[n]})
+			current = insertInt(current, anchor, n)
+			continue
+		}
+		from := indexOf(current, n)
+		current = append(current[:from], current[from+1:]...)
+		if from < anchor {
+			anchor--
+		}
+		if from != anchor {
+			p.add(Op{Kind: MoveChild, Path: path, From: from, Index: anchor})
+		}
+		current = insertInt(current, anchor, n)
+	}
+
+	for n, o := range match {
+		if o != -1 {
+			p.element(&old.Children[o], &new.Children[n], appendPath(path, n))
+		}
+	}
+}
+
+// add appends operation to patch
+func (p *Patch) add(op Op) {
+	*p = append(*p, op)
+}
+
+// matchChildren returns index of matching old child for each new child or -1
+func matchChildren(old, new []Element) []int {
+	type identity struct {
+		name, key string
+		keyed     bool
+	}
+	id := func(e *Element) identity {
+		if k, ok := e.Attributes[KeyAttribute]; ok && len(k) != 0 {
+			return identity{e.Name, k[0], true}
+		}
+		return identity{name: e.Name}
+	}
+
+	free := map[identity][]int{}
+	for i := range old {
+		k := id(&old[i])
+		free[k] = append(free[k], i)
+	}
+
+	match := make([]int, len(new))
+	for i := range new {
+		k := id(&new[i])
+		if q := free[k]; len(q) != 0 {
+			match[i] = q[0]
+			free[k] = q[1:]
+		} else {
+			match[i] = -1
+		}
+	}
+	return match
+}
+
+// longestIncreasing returns set of values that form longest increasing
+// subsequence of seq
+func longestIncreasing(seq []int) map[int]bool {
+	// tails[l] is index in seq of the smallest tail of subsequence with length l+1
+	tails := make([]int, 0, len(seq))
+	prev := make([]int, len(seq))
+	for i, v := range seq {
+		l := sort.Search(len(tails), func(j int) bool { return seq[tails[j]] >= v })
+		if l > 0 {
+			prev[i] = tails[l-1]
+		} else {
+			prev[i] = -1
+		}
+		if l == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[l] = i
+		}
+	}
+
+	res := make(map[int]bool, len(tails))
+	if len(tails) == 0 {
+		return res
+	}
+	for i := tails[len(tails)-1]; i != -1; i = prev[i] {
+		res[seq[i]] = true
+	}
+	return res
+}
+
+// Apply applies patch to e in place
+func Apply(e *Element, patch Patch) error {
+	for _, op := range patch {
+		target := e
+		for _, i := range op.Path {
+			if i < 0 || i >= len(target.Children) {
+				return ErrPatch.Path.Args(op.Path)
+			}
+			target = &target.Children[i]
+		}
+
+		switch op.Kind {
+		case InsertChild:
+			if op.Index < 0 || op.Index > len(target.Children) {
+				return ErrPatch.Index.Args(op.Index, op.Path)
+			}
+			target.Children = append(target.Children, Element{})
+			copy(target.Children[op.Index+1:], target.Children[op.Index:])
+			target.Children[op.Index] = op.Element
+		case RemoveChild:
+			if op.Index < 0 || op.Index >= len(target.Children) {
+				return ErrPatch.Index.Args(op.Index, op.Path)
+			}
+			target.Children = append(target.Children[:op.Index], target.Children[op.Index+1:]...)
+			if len(target.Children) == 0 {
+				target.Children = nil
+			}
+		case MoveChild:
+			if op.From < 0 || op.From >= len(target.Children) {
+				return ErrPatch.Index.Args(op.From, op.Path)
+			}
+			if op.Index < 0 || op.Index >= len(target.Children) {
+				return ErrPatch.Index.Args(op.Index, op.Path)
+			}
+			ch := target.Children[op.From]
+			if op.From < op.Index {
+				copy(target.Children[op.From:], target.Children[op.From+1:op.Index+1])
+			} else {
+				copy(target.Children[op.Index+1:], target.Children[op.Index:op.From])
+			}
+			target.Children[op.Index] = ch
+		case SetAttribute:
+			if target.Attributes == nil {
+				target.Attributes = Attribs{}
+			}
+			target.Attributes[op.Name] = append([]string(nil), op.Values...)
+		case RemoveAttribute:
+			delete(target.Attributes, op.Name)
+		case SetStyle:
+			if target.Style == nil {
+				target.Style = goss.Style{}
+			}
+			target.Style[op.Name] = append([]interface{}(nil), op.Style...)
+		case RemoveStyle:
+			delete(target.Style, op.Name)
+		default:
+			return ErrPatch.Kind.Args(op.Kind)
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns sorted union of keys
+func sortedKeys(a, b Attribs) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func indexOf(s []int, v int) int {
+	for i, x := range s {
+		if x == v {
+			return i
+		}
+	}
+	return -1
+}
+
+func insertInt(s []int, i, v int) []int {
+	s = append(s, 0)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+// appendPath returns copy of path with i appended so ops do not share memory
+func appendPath(path []int, i int) []int {
+	res := make([]int, len(path)+1)
+	copy(res, path)
+	res[len(path)] = i
+	return res
+}
@@ -0,0 +1,112 @@
+package goml
+
+import (
+	"testing"
+
+	"github.com/jakubDoka/goml/core"
+	"github.com/jakubDoka/goml/goss"
+)
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		desc, old, new string
+		ops            []OpKind
+	}{
+		{
+			desc: "same",
+			old:  `<div a="b">hello<span/></>`,
+			new:  `<div a="b">hello<span/></>`,
+		},
+		{
+			desc: "attributes",
+			old:  `<div a="b" c="d" e=["f" "g"]/>`,
+			new:  `<div a="b" e=["f" "h"] i="j"/>`,
+			ops:  []OpKind{RemoveAttribute, SetAttribute, SetAttribute},
+		},
+		{
+			desc: "style",
+			old:  `<div style="a: 1; b: c;"/>`,
+			new:  `<div style="a: 1f; d: e;"/>`,
+			ops:  []OpKind{SetAttribute, SetStyle, RemoveStyle, SetStyle},
+		},
+		{
+			desc: "text",
+			old:  `<div>hello</>`,
+			new:  `<div>bye</>`,
+			ops:  []OpKind{SetAttribute},
+		},
+		{
+			desc: "insert and remove",
+			old:  `<div><a/><b/><c/></>`,
+			new:  `<div><b/><d/><c/><e/></>`,
+			ops:  []OpKind{RemoveChild, InsertChild, InsertChild},
+		},
+		{
+			desc: "keyed move",
+			old:  `<div><a key="1"/><a key="2"/><a key="3"/></>`,
+			new:  `<div><a key="2"/><a key="3"/><a key="1" x="y"/></>`,
+			ops:  []OpKind{MoveChild, SetAttribute},
+		},
+		{
+			desc: "unkeyed reorder",
+			old:  `<div><a/><b/><c/><d/></>`,
+			new:  `<div><d/><a/><c/><b/></>`,
+			ops:  []OpKind{MoveChild, MoveChild},
+		},
+		{
+			desc: "key change",
+			old:  `<div><a key="1"/><a key="2"/></>`,
+			new:  `<div><a key="2"/><a key="3"/></>`,
+			ops:  []OpKind{RemoveChild, InsertChild},
+		},
+		{
+			desc: "nested",
+			old:  `<div><a><b/><c/></><d/></>`,
+			new:  `<div><d/><a><c/></></>`,
+			ops:  []OpKind{MoveChild, RemoveChild},
+		},
+		{
+			desc: "clear",
+			old:  `<div><a/><b/></>`,
+			new:  `<div/>`,
+			ops:  []OpKind{RemoveChild, RemoveChild},
+		},
+	}
+	p := NParser(&goss.Parser{})
+	p.AddDefinitions("div", "a", "b", "c", "d", "e", "span")
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			old, err := p.Parse([]byte(tC.old))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			new, err := p.Parse([]byte(tC.new))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			patch := Diff(old, new)
+			var kinds []OpKind
+			for _, op := range patch {
+				kinds = append(kinds, op.Kind)
+			}
+			core.TestEqual(t, kinds, tC.ops)
+
+			if err := Apply(&old, patch); err != nil {
+				t.Error(err)
+				return
+			}
+			core.TestEqual(t, noSpans(old.Children), noSpans(new.Children))
+		})
+	}
+
+	e := NDiv()
+	if err := Apply(&e, Patch{{Kind: RemoveChild, Path: []int{0}}}); !ErrPatch.Path.SameSurface(err) {
+		t.Error(err)
+	}
+	if err := Apply(&e, Patch{{Kind: RemoveChild}}); !ErrPatch.Index.SameSurface(err) {
+		t.Error(err)
+	}
+}
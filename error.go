@@ -0,0 +1,145 @@
+package goml
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jakubDoka/goml/core"
+	"github.com/jakubDoka/sterr"
+)
+
+// ParseError is returned by Parse when source is malformed
+type ParseError struct {
+	// File is name of the file passed to Parser.ParseFile or imported file
+	// where error happened, it is empty for Parser.Parse, callers that know the
+	// file name can set it so it is included in messages
+	File string
+	// Line and Column are 1-based, Column counts runes
+	Line, Column int
+	// Offset is byte offset from the start of source
+	Offset int
+	// Kind is one of error variables of the package, for example ErrDiv.Incomplete,
+	// with arguments filled in
+	Kind error
+	// Imports are locations of import directives that led to File formatted as
+	// file:line:column, the innermost first
+	Imports []string
+
+	source []byte
+}
+
+// NParseError creates ParseError of given kind at pos in source. Source can be
+// nil when it is not known, Column then counts bytes instead of runes and
+// Excerpt is empty.
+func NParseError(file string, source []byte, pos core.Pos, kind error) *ParseError {
+	line, column, offset := position(source, pos)
+	return &ParseError{
+		File:   file,
+		Line:   line,
+		Column: column,
+		Offset: offset,
+		Kind:   kind,
+		source: source,
+	}
+}
+
+// newParseError creates ParseError from the error parser raised
+func (p *Parser) newParseError() *ParseError {
+	return NParseError(p.file, p.Source, p.ErrPos, p.ErrKind)
+}
+
+// position converts pos into 1-based line, column in runes and offset clamped
+// to the source
+func position(source []byte, pos core.Pos) (line, column, offset int) {
+	if source == nil {
+		return pos.Line + 1, pos.Column + 1, pos.Offset
+	}
+	offset = pos.Offset
+	if offset > len(source) {
+		offset = len(source)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	lineStart := offset - pos.Column
+	if lineStart < 0 {
+		lineStart = 0
+	}
+	return pos.Line + 1, utf8.RuneCount(source[lineStart:offset]) + 1, offset
+}
+
+// location formats pos as file:line:column
+func (p *Parser) location(pos core.Pos) string {
+	line, column, _ := position(p.Source, pos)
+	return fmt.Sprintf("%s:%d:%d", p.file, line, column)
+}
+
+// wrapError raises kind with err as its cause
+func (p *Parser) wrapError(kind sterr.Err, err error) {
+	p.Error(kind)
+	p.Err = kind.Wrap(p.ReportError().Wrap(err))
+	p.ErrKind = kind.Wrap(err)
+}
+
+// Error implements error interface, format is 'file:line:column: message' followed
+// by import locations
+func (e *ParseError) Error() string {
+	var msg string
+	if e.File == "" {
+		msg = fmt.Sprintf("%d:%d: %v", e.Line, e.Column, e.Kind)
+	} else {
+		msg = fmt.Sprintf("%s:%d:%d: %v", e.File, e.Line, e.Column, e.Kind)
+	}
+	for _, imp := range e.Imports {
+		msg += ", imported at " + imp
+	}
+	return msg
+}
+
+// Unwrap returns e.Kind
+func (e *ParseError) Unwrap() error {
+	return e.Kind
+}
+
+// Is reports whether e is of kind target so errors.Is(err, ErrDiv.Incomplete) works,
+// arguments of kinds are not compared
+func (e *ParseError) Is(target error) bool {
+	t, ok := target.(sterr.Err)
+	return ok && t.SameSurface(e.Kind)
+}
+
+// Excerpt returns line where error happened and a caret pointing to the
+// column under it:
+//
+//	<div a="b" c/>
+//	            ^
+//
+// Tabs are preserved so caret stays aligned.
+func (e *ParseError) Excerpt() string {
+	if e.source == nil {
+		return ""
+	}
+	start := bytes.LastIndexByte(e.source[:e.Offset], '\n') + 1
+	end := bytes.IndexByte(e.source[e.Offset:], '\n')
+	if end == -1 {
+		end = len(e.source)
+	} else {
+		end += e.Offset
+	}
+	line := strings.TrimRight(string(e.source[start:end]), "\r")
+
+	var sb strings.Builder
+	sb.WriteString(line)
+	sb.WriteByte('\n')
+	for _, r := range string(e.source[start:e.Offset]) {
+		if r == '\t' {
+			sb.WriteByte('\t')
+		} else {
+			sb.WriteByte(' ')
+		}
+	}
+	sb.WriteString("^\n")
+	return sb.String()
+}
@@ -0,0 +1,46 @@
+package goml
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	p := NParser(nil)
+	p.AddDefinitions("div")
+	_, err := p.Parse([]byte("<div>\n\tпривет <div a=/>\n</>"))
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Error(err)
+		return
+	}
+	if pe.Line != 2 || pe.Column != 16 || pe.Offset != 27 {
+		t.Error(pe.Line, pe.Column, pe.Offset)
+	}
+	if !errors.Is(err, ErrAttrib.ValueStart) || errors.Is(err, ErrAttrib.Incomplete) {
+		t.Error(err)
+	}
+
+	pe.File = "a.goml"
+	if err.Error() != "a.goml:2:16: "+ErrAttrib.ValueStart.Error() {
+		t.Error(err)
+	}
+	if ex := pe.Excerpt(); ex != "\tпривет <div a=/>\n\t              ^\n" {
+		t.Errorf("%q", ex)
+	}
+
+	_, err = p.Parse([]byte("<div>"))
+	if !errors.As(err, &pe) || !errors.Is(err, ErrDiv.MissingClosure) || pe.Line != 1 {
+		t.Error(err)
+	}
+
+	_, diags := p.ParseRecover([]byte("<div>\n\tпривет <div a=/>\n</>"))
+	if len(diags) != 1 || !errors.As(diags[0].Err, &pe) {
+		t.Error(diags)
+		return
+	}
+	if pe.Line != 2 || pe.Column != 16 || !errors.Is(pe, ErrAttrib.ValueStart) {
+		t.Error(pe)
+	}
+}
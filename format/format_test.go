@@ -0,0 +1,170 @@
+package format
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jakubDoka/goml"
+	"github.com/jakubDoka/goml/goss"
+	"github.com/jakubDoka/sterr"
+)
+
+func TestGoml(t *testing.T) {
+	testCases := []struct {
+		desc, input, output string
+		err                 sterr.Err
+	}{
+		{
+			desc: "layout",
+			input: `<#> prefab
+definition <#>
+<!yes_no><div><button onclick={yes}>yes</><button onclick={no}>
+	no
+</></><!/>
+
+
+<div>Hello,   is\  monday
+today?</>
+<yes_no yes="yes" no="no"/>`,
+			output: `<#> prefab
+definition <#>
+<!yes_no>
+	<div>
+		<button onclick={yes}>yes</>
+		<button onclick={no}>no</>
+	</>
+<!/>
+
+<div>Hello, is\  monday today?</>
+<yes_no no="no" yes="yes"/>
+`,
+		},
+		{
+			desc:   "attributes",
+			input:  `<div x="a\"b" flag b=["a" {c}] a={a}></>`,
+			output: `<div a={a} b=["a" {c}] flag x="a\"b"></>` + "\n",
+		},
+		{
+			desc:   "defaults",
+			input:  `<div b="x {a="}  <"}" a={a="}"}>{b="  <}"}   x</>`,
+			output: `<div a={a="}"} b="x {a="}  <"}">{b="  <}"} x</>` + "\n",
+		},
+		{
+			desc:   "last boolean",
+			input:  `<div flag a="a"/>`,
+			output: `<div a="a" flag="true"/>` + "\n",
+		},
+		{
+			desc:   "repeated boolean",
+			input:  `<div flag flag a="a"/>`,
+			output: `<div a="a" flag flag=["true"]/>` + "\n",
+		},
+		{
+			desc:   "repeated attribute",
+			input:  `<div x="b" x flag a="a"/>`,
+			output: `<div a="a" flag x="b" x=["true"]/>` + "\n",
+		},
+		{
+			desc:   "import",
+			input:  "<@import \"a>b.goml\"><div/>",
+			output: "<@import \"a>b.goml\">\n<div/>\n",
+		},
+		{
+			desc:  "missing closure",
+			input: `<div>`,
+			err:   goml.ErrDiv.MissingClosure,
+		},
+		{
+			desc:  "list",
+			input: `<div a=["a"  "b"]/>`,
+			err:   goml.ErrAttrib.BetweenByte,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			out, err := Goml([]byte(tC.input))
+			if !tC.err.SameSurface(err) {
+				t.Error(err)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if string(out) != tC.output {
+				t.Errorf("\n%s\n%s", out, tC.output)
+			}
+			again, err := Goml(out)
+			if err != nil || string(again) != string(out) {
+				t.Errorf("not idempotent\n%s", again)
+			}
+		})
+	}
+}
+
+func TestGomlMeaning(t *testing.T) {
+	inputs := []string{
+		`<div flag a="a"/>`,
+		`<div flag flag a="a"/>`,
+		`<div x="b" x flag a="a"/>`,
+		`<div b=["a" "b"] a="a" b=["c"]/>`,
+	}
+	p := goml.NParser(nil)
+	p.AddDefinitions("div")
+	for _, input := range inputs {
+		out, err := Goml([]byte(input))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		a, err := p.Parse([]byte(input))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		b, err := p.Parse(out)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !reflect.DeepEqual(a.Children[0].Attributes, b.Children[0].Attributes) {
+			t.Errorf("%s\n%s", input, out)
+		}
+	}
+}
+
+func TestGoss(t *testing.T) {
+	out, err := Goss([]byte(`
+style{
+    some_floats:   10f 10.4f;
+    b: hello
+	 10i;
+    sub_style{
+        anonymous: {a:b;c:d;} {e:f;i{j:k;}};
+    }
+}
+another_style{property: value;}`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if string(out) != `style{
+	some_floats: 10f 10.4f;
+	b:           hello 10i;
+	sub_style{
+		anonymous: {a: b; c: d;} {e: f; i{j: k;}};
+	}
+}
+
+another_style{
+	property: value;
+}
+` {
+		t.Error(string(out))
+	}
+
+	_, err = Goss([]byte(`a{b}`))
+	if !goss.ErrExpectedByte.SameSurface(err) {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,473 @@
+// Package format rewrites goml and goss source into canonical layout
+package format
+
+import (
+	"sort"
+
+	"github.com/jakubDoka/goml"
+	"github.com/jakubDoka/goml/core"
+)
+
+// node kinds
+const (
+	element = iota
+	prefab
+	text
+	comment
+	directive
+)
+
+// node is syntactic goml node, unlike goml.Element it preserves
+// comments, prefab definitions and raw attribute values
+type node struct {
+	kind     int
+	name     string
+	raw      string
+	attribs  []attrib
+	children []node
+	closed   bool // self-closing element
+	blank    bool // node is preceded by empty line
+}
+
+// attrib holds attribute with value as written in source,
+// raw is empty for boolean attribute
+type attrib struct {
+	name, raw string
+}
+
+// gomlFormatter builds node tree from source
+type gomlFormatter struct {
+	core.Parser
+	stack    []node
+	root     node
+	lastLine int
+	buff     []byte
+}
+
+// Goml formats goml source. Formatting is purely syntactic so definitions and
+// prefabs do not have to be known. Attributes are sorted by name, repeated
+// attributes are kept in order they were written in, children are
+// indented by tab and '</>' gets its own line unless element contains just text.
+// Comments and single empty lines between elements are preserved.
+func Goml(src []byte) ([]byte, error) {
+	f := gomlFormatter{}
+	f.Restart(src)
+	if !f.parse() {
+		return nil, f.Err
+	}
+
+	for i, n := range f.root.children {
+		if i != 0 && n.blank {
+			f.buff = append(f.buff, '\n')
+		}
+		f.node(n, 0)
+	}
+
+	return f.buff, nil
+}
+
+// parse builds f.root
+func (f *gomlFormatter) parse() bool {
+	for f.SkipSpace() {
+		blank := f.Line-f.lastLine > 1 && f.lastLine != -1
+		var n node
+		switch f.Ch {
+		case '<':
+			if f.AdvanceOr(goml.ErrDiv.Incomplete) {
+				return false
+			}
+			switch f.Ch {
+			case '#':
+				if !f.comment(&n) {
+					return false
+				}
+			case '@':
+				if !f.directive(&n) {
+					return false
+				}
+			case '/':
+				if f.Check('>', goml.ErrDiv.AfterSlash) || !f.close(false) {
+					return false
+				}
+				f.lastLine = f.Line
+				continue
+			case '!':
+				if f.AdvanceOr(goml.ErrDiv.Incomplete) {
+					return false
+				}
+				if f.Ch == '/' {
+					if f.Check('>', goml.ErrDiv.AfterSlash) || !f.close(true) {
+						return false
+					}
+					f.lastLine = f.Line
+					continue
+				}
+				n.kind = prefab
+				if !f.element(&n) {
+					return false
+				}
+			default:
+				if !f.element(&n) {
+					return false
+				}
+			}
+		default:
+			if !f.text(&n) {
+				return false
+			}
+		}
+
+		f.lastLine = f.Line
+		n.blank = blank
+		if (n.kind == element || n.kind == prefab) && !n.closed {
+			f.stack = append(f.stack, n)
+			f.lastLine = -1 // no empty line at the start of block
+		} else {
+			f.add(n)
+		}
+	}
+
+	if len(f.stack) != 0 {
+		f.Error(goml.ErrDiv.MissingClosure)
+		return false
+	}
+
+	return true
+}
+
+// close pops element from stack and adds it to its parent
+func (f *gomlFormatter) close(prefabEnd bool) bool {
+	if len(f.stack) == 0 {
+		f.Error(goml.ErrDiv.ExtraClosure)
+		return false
+	}
+	n := f.stack[len(f.stack)-1]
+	f.stack = f.stack[:len(f.stack)-1]
+	if (n.kind == prefab) != prefabEnd {
+		f.Error(goml.ErrDiv.ExtraClosure)
+		return false
+	}
+	f.add(n)
+	return true
+}
+
+// add appends node to element on stack top
+func (f *gomlFormatter) add(n node) {
+	parent := &f.root
+	if len(f.stack) != 0 {
+		parent = &f.stack[len(f.stack)-1]
+	}
+	parent.children = append(parent.children, n)
+}
+
+// comment reads comment, content is preserved as is
+func (f *gomlFormatter) comment(n *node) bool {
+	n.kind = comment
+	if f.Check('>', goml.ErrComment.AfterHash) {
+		return false
+	}
+	start := f.I + 1
+	for {
+		equal, ok := f.CheckSlice(goml.CommentEnd)
+		if !ok {
+			f.Error(goml.ErrComment.NotClosed)
+			return false
+		}
+		if equal && f.I >= start {
+			n.raw = string(f.Source[start:f.I])
+			f.Advance()
+			f.Advance()
+			return true
+		}
+		if f.Ch == '\n' {
+			f.NewLine()
+		}
+		f.Advance()
+	}
+}
+
+// directive reads directive such as import, it is preserved as is
+func (f *gomlFormatter) directive(n *node) bool {
+	n.kind = directive
+	start := f.I
+	for f.Advance() {
+		switch f.Ch {
+		case '"':
+			if !f.string() {
+				return false
+			}
+		case '>':
+			n.raw = string(f.Source[start:f.I])
+			return true
+		}
+	}
+	f.Error(goml.ErrDiv.Incomplete)
+	return false
+}
+
+// element reads element head
+func (f *gomlFormatter) element(n *node) bool {
+	n.name = string(f.Ident())
+	if n.name == "" {
+		f.Error(goml.ErrDiv.Identifier)
+		return false
+	}
+
+	for {
+		switch f.Ch {
+		case ' ':
+			if n.kind == prefab {
+				f.Error(goml.ErrPrefab.Attributes)
+				return false
+			}
+			if !f.attribute(n) {
+				return false
+			}
+		case '/':
+			if f.Check('>', goml.ErrDiv.AfterSlash) {
+				return false
+			}
+			n.closed = true
+			return true
+		case '>':
+			return true
+		default:
+			f.Error(goml.ErrDiv.AfterIdent)
+			return false
+		}
+	}
+}
+
+// attribute reads one attribute
+func (f *gomlFormatter) attribute(n *node) bool {
+	if f.AdvanceOr(goml.ErrDiv.Incomplete) {
+		return false
+	}
+	a := attrib{name: string(f.Ident())}
+	switch f.Ch {
+	case ' ':
+	case '=':
+		if f.AdvanceOr(goml.ErrAttrib.Incomplete) {
+			return false
+		}
+		start := f.I
+		switch f.Ch {
+		case '"', '{':
+			if !f.value() {
+				return false
+			}
+		case '[':
+			if !f.list() {
+				return false
+			}
+		default:
+			f.Error(goml.ErrAttrib.ValueStart)
+			return false
+		}
+		a.raw = string(f.Source[start:f.I])
+	default:
+		f.Error(goml.ErrAttrib.Assignmant)
+		return false
+	}
+	n.attribs = append(n.attribs, a)
+	return true
+}
+
+// list reads list value, cursor ends after ']'
+func (f *gomlFormatter) list() bool {
+	for {
+		if f.AdvanceOr(goml.ErrAttrib.ListIncomplete) {
+			return false
+		}
+		switch f.Ch {
+		case '"', '{':
+			if !f.value() {
+				return false
+			}
+		default:
+			f.Error(goml.ErrAttrib.BetweenByte)
+			return false
+		}
+		switch f.Ch {
+		case ']':
+			return !f.AdvanceOr(goml.ErrAttrib.Incomplete)
+		case ' ':
+		default:
+			f.Error(goml.ErrAttrib.BetweenByte)
+			return false
+		}
+	}
+}
+
+// value skips string or template, cursor ends after it
+func (f *gomlFormatter) value() bool {
+	if f.Ch == '{' {
+		return f.template() && !f.AdvanceOr(goml.ErrAttrib.Incomplete)
+	}
+	return f.string() && !f.AdvanceOr(goml.ErrAttrib.Incomplete)
+}
+
+// string skips string, cursor ends on closing '"'
+func (f *gomlFormatter) string() bool {
+	for f.Advance() {
+		switch f.Ch {
+		case '\\':
+			f.Advance()
+		case '\n':
+			f.NewLine()
+		case '{':
+			if f.I+1 >= len(f.Source) || f.Source[f.I+1] == '{' {
+				f.Advance()
+			} else if !f.template() {
+				return false
+			}
+		case '"':
+			return true
+		}
+	}
+	f.Error(goml.ErrStringNotTerminated)
+	return false
+}
+
+// template skips template parameter, that can contain default value,
+// cursor ends on closing '}'
+func (f *gomlFormatter) template() bool {
+	for f.Advance() {
+		switch f.Ch {
+		case '"':
+			if !f.string() {
+				return false
+			}
+		case '}':
+			return true
+		}
+	}
+	f.Error(goml.ErrStringNotTerminated)
+	return false
+}
+
+// text reads text, unescaped whitespace is collapsed into single space
+func (f *gomlFormatter) text(n *node) bool {
+	n.kind = text
+	var buff []byte
+	space := false
+	for {
+		switch f.Ch {
+		case '<':
+			f.Degrade()
+			n.raw = string(buff)
+			return true
+		case ' ', '\t', '\r', '\n':
+			if f.Ch == '\n' {
+				f.NewLine()
+			}
+			space = true
+		default:
+			if space {
+				buff = append(buff, ' ')
+				space = false
+			}
+			start := f.I
+			switch {
+			case f.Ch == '\\':
+				if f.AdvanceOr(goml.ErrEscape.Incomplete) {
+					return false
+				}
+			case f.Ch == '{' && f.I+1 < len(f.Source) && f.Source[f.I+1] == '{':
+				f.Advance()
+			case f.Ch == '{':
+				// default value of template is kept as is
+				if !f.template() {
+					return false
+				}
+			}
+			buff = append(buff, f.Source[start:f.I+1]...)
+		}
+		if !f.Advance() {
+			n.raw = string(buff)
+			return true
+		}
+	}
+}
+
+// node writes node and its children on given indentation level
+func (f *gomlFormatter) node(n node, level int) {
+	f.indent(level)
+	switch n.kind {
+	case text:
+		f.buff = append(f.buff, n.raw...)
+	case comment:
+		f.buff = append(f.buff, "<#>"...)
+		f.buff = append(f.buff, n.raw...)
+		f.buff = append(f.buff, "<#>"...)
+	case directive:
+		f.buff = append(f.buff, '<')
+		f.buff = append(f.buff, n.raw...)
+		f.buff = append(f.buff, '>')
+	case prefab:
+		f.buff = append(f.buff, "<!"...)
+		f.buff = append(f.buff, n.name...)
+		f.buff = append(f.buff, ">\n"...)
+		f.children(n, level+1)
+		f.indent(level)
+		f.buff = append(f.buff, "<!/>"...)
+	default:
+		f.buff = append(f.buff, '<')
+		f.buff = append(f.buff, n.name...)
+		sort.SliceStable(n.attribs, func(i, j int) bool {
+			return n.attribs[i].name < n.attribs[j].name
+		})
+		for i, a := range n.attribs {
+			f.buff = append(f.buff, ' ')
+			f.buff = append(f.buff, a.name...)
+			if a.raw == "" {
+				// boolean attribute has to be followed by another attribute,
+				// list appends to values of repeated attribute instead of
+				// replacing them
+				switch {
+				case i != len(n.attribs)-1:
+				case i != 0 && n.attribs[i-1].name == a.name:
+					f.buff = append(f.buff, `=["true"]`...)
+				default:
+					f.buff = append(f.buff, `="true"`...)
+				}
+			} else {
+				f.buff = append(f.buff, '=')
+				f.buff = append(f.buff, a.raw...)
+			}
+		}
+		switch {
+		case n.closed:
+			f.buff = append(f.buff, "/>"...)
+		case len(n.children) == 0:
+			f.buff = append(f.buff, "></>"...)
+		case len(n.children) == 1 && n.children[0].kind == text:
+			f.buff = append(f.buff, '>')
+			f.buff = append(f.buff, n.children[0].raw...)
+			f.buff = append(f.buff, "</>"...)
+		default:
+			f.buff = append(f.buff, ">\n"...)
+			f.children(n, level+1)
+			f.indent(level)
+			f.buff = append(f.buff, "</>"...)
+		}
+	}
+	f.buff = append(f.buff, '\n')
+}
+
+// children writes children of n
+func (f *gomlFormatter) children(n node, level int) {
+	for i, ch := range n.children {
+		if i != 0 && ch.blank {
+			f.buff = append(f.buff, '\n')
+		}
+		f.node(ch, level)
+	}
+}
+
+// indent writes level tabs
+func (f *gomlFormatter) indent(level int) {
+	for i := 0; i < level; i++ {
+		f.buff = append(f.buff, '\t')
+	}
+}
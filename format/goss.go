@@ -0,0 +1,167 @@
+package format
+
+import (
+	"github.com/jakubDoka/goml/core"
+	"github.com/jakubDoka/goml/goss"
+)
+
+// property is a goss property with raw values or a sub style
+type property struct {
+	name   string
+	values []string
+	sub    []property
+	isSub  bool
+}
+
+// gossFormatter collects properties from goss source
+type gossFormatter struct {
+	core.Parser
+	buff []byte
+}
+
+// Goss formats goss source, source is validated by goss.Parser first. Every
+// property gets its own line and values of properties within one style are
+// aligned to the same column, values themselves are written as they are in
+// source.
+func Goss(src []byte) ([]byte, error) {
+	var gp goss.Parser
+	if _, err := gp.Parse(src); err != nil {
+		return nil, err
+	}
+
+	f := gossFormatter{}
+	f.Restart(src)
+	first := true
+	for f.SkipSpace() {
+		name := string(f.Ident())
+		f.skipSpace()
+		props := f.style()
+		if !first {
+			f.buff = append(f.buff, '\n')
+		}
+		first = false
+		f.block(property{name: name, sub: props, isSub: true}, 0)
+	}
+
+	return f.buff, nil
+}
+
+// style reads properties until '}', it expects p.Ch == '{'
+func (f *gossFormatter) style() (props []property) {
+	for {
+		f.Advance()
+		f.skipSpace()
+		if f.Ch == '}' {
+			return
+		}
+		prop := property{name: string(f.Ident())}
+		f.skipSpace()
+		if f.Ch == '{' {
+			prop.isSub = true
+			prop.sub = f.style()
+		} else {
+			// ':'
+			for {
+				f.Advance()
+				f.skipSpace()
+				if f.Ch == ';' {
+					break
+				}
+				prop.values = append(prop.values, f.value())
+			}
+		}
+		props = append(props, prop)
+	}
+}
+
+// value returns raw value, inline styles are formatted
+func (f *gossFormatter) value() string {
+	if f.Ch == '{' {
+		return string(f.inline(f.style()))
+	}
+
+	start := f.I
+	for f.Peek() {
+		switch f.Source[f.I+1] {
+		case ' ', '\t', '\r', '\n', ';', '{', '}':
+			return string(f.Source[start : f.I+1])
+		}
+		f.Advance()
+	}
+	return string(f.Source[start:])
+}
+
+// inline formats style that is used as value
+func (f *gossFormatter) inline(props []property) []byte {
+	buff := []byte{'{'}
+	for i, p := range props {
+		if i != 0 {
+			buff = append(buff, ' ')
+		}
+		buff = append(buff, p.name...)
+		if p.isSub {
+			buff = append(buff, f.inline(p.sub)...)
+			continue
+		}
+		buff = append(buff, ':')
+		for _, v := range p.values {
+			buff = append(buff, ' ')
+			buff = append(buff, v...)
+		}
+		buff = append(buff, ';')
+	}
+	return append(buff, '}')
+}
+
+// block writes style block with its properties aligned
+func (f *gossFormatter) block(style property, level int) {
+	f.indent(level)
+	f.buff = append(f.buff, style.name...)
+	f.buff = append(f.buff, "{\n"...)
+
+	width := 0
+	for _, p := range style.sub {
+		if !p.isSub && len(p.name) > width {
+			width = len(p.name)
+		}
+	}
+
+	for _, p := range style.sub {
+		if p.isSub {
+			f.block(p, level+1)
+			continue
+		}
+		f.indent(level + 1)
+		f.buff = append(f.buff, p.name...)
+		f.buff = append(f.buff, ':')
+		for i := len(p.name); i < width; i++ {
+			f.buff = append(f.buff, ' ')
+		}
+		for _, v := range p.values {
+			f.buff = append(f.buff, ' ')
+			f.buff = append(f.buff, v...)
+		}
+		f.buff = append(f.buff, ";\n"...)
+	}
+
+	f.indent(level)
+	f.buff = append(f.buff, "}\n"...)
+}
+
+// skipSpace skips whitespace if p.Ch is whitespace
+func (f *gossFormatter) skipSpace() {
+	switch f.Ch {
+	case ' ', '\t', '\r', '\n':
+		if f.Ch == '\n' {
+			f.NewLine()
+		}
+		f.SkipSpace()
+	}
+}
+
+// indent writes level tabs
+func (f *gossFormatter) indent(level int) {
+	for i := 0; i < level; i++ {
+		f.buff = append(f.buff, '\t')
+	}
+}
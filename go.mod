@@ -1,6 +1,6 @@
 module github.com/jakubDoka/goml
 
-go 1.15
+go 1.16
 
 require (
 	github.com/jakubDoka/gogen v0.0.0-20210203193544-0b4c09955618
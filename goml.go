@@ -1,6 +1,7 @@
 package goml
 
 import (
+	"io/fs"
 	"strconv"
 	"strings"
 
@@ -45,12 +46,18 @@ var ErrDiv = struct {
 
 // ErrPrefab stores prefab related errors
 var ErrPrefab = struct {
-	Shadow, Outside, Ident, Attributes sterr.Err
+	Shadow, Outside, Ident, Attributes, Cycle, Default, Missing, Unexpected, Unknown, Slot sterr.Err
 }{
 	sterr.New("prefab cannot shadow existing element or prefab"),
 	sterr.New("prefab syntax outside a prefab block is not allowed"),
 	sterr.New("only identifier is allowed between '{}', found '%s' witch cannot be part of ident"),
 	sterr.New("prefab definition cannot have attributes"),
+	sterr.New("prefab uses itself: %s"),
+	sterr.New("default value of parameter has to be a string without parameters"),
+	sterr.New("prefab '%s' requires parameter '%s'"),
+	sterr.New("prefab '%s' has no parameter '%s'"),
+	sterr.New("prefab '%s' does not exist"),
+	sterr.New("prefab '%s' has no slot '%s'"),
 }
 
 // ErrAttrib stores attribute related errors
@@ -68,18 +75,40 @@ var ErrAttrib = struct {
 // CommentEnd is group of comment closing bytes
 var CommentEnd = []byte("<#>")
 
-// Parser takes a goml syntax and parses it into DivTree
+// Parser takes a goml syntax and parses it into DivTree, it is not safe for
+// concurrent use, use Parser.Freeze to parse from multiple goroutines
 type Parser struct {
 	gs      *goss.Parser
 	stack   DivStack
 	defined map[string]bool
+	schemas map[string]Schema
 	prefabs map[string]Element
-
+	// registry is set when parser is used by Registry
+	registry *Registry
+
+	fsys      fs.FS
+	file      string
+	imported  map[string]bool
+	origins   map[string]string // files prefabs were defined in
+	importing []string          // files that are being imported, for cycle detection
+	importErr *ParseError
+
+	// src is Source converted once so strings can be sliced from it
+	src          string
+	names        map[string]string
 	attribIdent  string
 	root, parsed Element
 	stringBuff   []rune
 	styleBuff    []byte
 	inPrefab     bool
+	start        core.Pos
+	textEnd      core.Pos
+
+	recovering, open bool
+	diagnostics      []Diagnostic
+
+	inDefault   bool
+	defaultBuff []rune
 
 	parser
 }
@@ -90,6 +119,7 @@ type Parser struct {
 func NParser(sp *goss.Parser) *Parser {
 	return &Parser{
 		defined: map[string]bool{},
+		schemas: map[string]Schema{},
 		prefabs: map[string]Element{},
 		gs:      sp,
 	}
@@ -103,10 +133,11 @@ func (p *Parser) AddDefinitions(names ...string) {
 	}
 }
 
-// RemoveDefinitions removes definitions from defSet
+// RemoveDefinitions removes definitions from defSet, schemas are removed as well
 func (p *Parser) RemoveDefinitions(names ...string) {
 	for _, name := range names {
 		delete(p.defined, name)
+		delete(p.schemas, name)
 	}
 }
 
@@ -115,13 +146,23 @@ func (p *Parser) ClearDefinitions() {
 	for name := range p.defined {
 		delete(p.defined, name)
 	}
+	for name := range p.schemas {
+		delete(p.schemas, name)
+	}
 }
 
-// ClearPrefabs clears all prefabs so they can be redefined
+// ClearPrefabs clears all prefabs so they can be redefined, files imported so
+// far can be imported again
 func (p *Parser) ClearPrefabs() {
 	for name := range p.prefabs {
 		delete(p.prefabs, name)
 	}
+	for name := range p.imported {
+		delete(p.imported, name)
+	}
+	for name := range p.origins {
+		delete(p.origins, name)
+	}
 }
 
 // AddPrefabs adds prefabs from Source
@@ -134,23 +175,53 @@ func (p *Parser) AddPrefabs(Source []byte) error {
 func (p *Parser) RemovePrefabs(names ...string) {
 	for _, n := range names {
 		delete(p.prefabs, n)
+		delete(p.origins, n)
 	}
 }
 
 // Restart restarts parser state for another parsing
 func (p *Parser) Restart(Source []byte) {
 	p.parser.Restart(Source)
+	p.src = string(Source)
 	p.root = NDiv()
 	p.stack = p.stack[:0]
 	p.inPrefab = false
+	p.importErr = nil
 }
 
-// Parse ...
+// Parse parses Source into tree of elements, root element has no name and contains
+// all top level elements, parsing stops on first error which is returned as *ParseError
 func (p *Parser) Parse(Source []byte) (Element, error) {
+	p.recovering = false
+	p.parse(Source)
+	if p.importErr != nil {
+		return p.root, p.importErr
+	}
+	if p.Err != nil {
+		return p.root, p.newParseError()
+	}
+	return p.root, nil
+}
+
+// ParseRecover parses Source like Parse but it does not stop on first error, malformed
+// element, attribute or list is skipped and parsing continues. Returned tree contains
+// everything that was parsed and diagnostics hold all encountered errors in order
+func (p *Parser) ParseRecover(Source []byte) (Element, []Diagnostic) {
+	p.recovering = true
+	p.diagnostics = nil
+	p.parse(Source)
+	return p.root, p.diagnostics
+}
+
+// parse performs the parsing, result is stored in p.root
+func (p *Parser) parse(Source []byte) {
 	p.Restart(Source)
+	p.resetNames()
 	for p.SkipSpace() && !p.Failed() {
+		begin := p.I
 		switch p.Ch {
 		case '<':
+			p.start = p.Pos()
 			if p.AdvanceOr(ErrDiv.Incomplete) {
 				break
 			}
@@ -176,6 +247,8 @@ func (p *Parser) Parse(Source []byte) (Element, error) {
 				if !p.element(true) {
 					break
 				}
+			case '@':
+				p.directive()
 			case '#':
 				if p.Check('>', ErrComment.AfterHash) {
 					break
@@ -187,11 +260,13 @@ func (p *Parser) Parse(Source []byte) (Element, error) {
 						break
 					}
 					if equal {
-						p.Advance()
 						p.Advance()
 						p.Advance()
 						break
 					} else {
+						if p.Ch == '\n' {
+							p.NewLine()
+						}
 						p.Advance()
 					}
 				}
@@ -203,25 +278,38 @@ func (p *Parser) Parse(Source []byte) (Element, error) {
 		default:
 			p.textElement()
 		}
+
+		if p.recovering && p.Failed() {
+			p.recover(begin)
+		}
 	}
 
 	if len(p.stack) != 0 && p.Err == nil {
 		p.Error(ErrDiv.MissingClosure)
+		if p.recovering {
+			p.diagnose()
+			p.closeAll()
+		}
 	}
 
-	return p.root, p.Err
+	p.root.Span.End = p.SourceEnd()
+
 }
 
 // textElement parses a text paragraph into element with text attribute
 func (p *Parser) textElement() bool {
-	p.parsed = NDiv()
+	p.parsed = newElement()
 	p.parsed.Name = "text"
 	p.attribIdent = "text"
+	p.parsed.Span.Start = p.Pos()
+	p.textEnd = p.End()
 	p.Degrade()
-	if !p.string('<', true) {
+	text, ok := p.string('<', true)
+	if !ok {
 		return false
 	}
-	p.parsed.Attributes[p.attribIdent] = []string{string(p.stringBuff)}
+	p.parsed.Attributes[p.attribIdent] = []string{text}
+	p.parsed.Span.End = p.textEnd
 
 	if p.Peek() {
 		p.Degrade()
@@ -240,9 +328,18 @@ func (p *Parser) elementEnd(prefab bool) bool {
 
 	if p.stack.CanPop() {
 		d := p.stack.Pop()
+		d.Span.End = p.End()
 		if p.inPrefab && prefab {
 			p.prefabs[d.Name] = d
 			p.inPrefab = false
+			if p.file != "" {
+				if p.origins == nil {
+					p.origins = map[string]string{}
+				}
+				p.origins[d.Name] = p.file
+			}
+		} else if pf, ok := p.prefab(d.Name); ok && !p.inPrefab {
+			return p.instantiate(pf, &d)
 		} else {
 			p.add(d)
 		}
@@ -256,26 +353,28 @@ func (p *Parser) elementEnd(prefab bool) bool {
 // Element parses a element definition with its attributes, if element contains children, it will push it to stack
 // othervise bits is pushed to p.current()
 func (p *Parser) element(isPrefab bool) bool {
-	p.parsed = NDiv()
-	p.parsed.Name = string(p.Ident())
+	p.open = true
+	p.parsed = newElement()
+	p.parsed.Span.Start = p.start
+	p.parsed.Name = p.intern(p.Ident())
 
 	if p.parsed.Name == "" {
 		p.Error(ErrDiv.Identifier)
 		return false
 	}
 
-	prefab, pok := p.prefabs[p.parsed.Name]
+	// prefabs used inside prefab definition are expanded when outer prefab is
+	// instantiated so they can also be defined later
+	prefab, pok := p.prefab(p.parsed.Name)
 	dok := p.defined[p.parsed.Name]
-	if p.inPrefab {
+	if isPrefab {
 		if pok {
 			p.Error(ErrPrefab.Shadow)
 			return false
 		}
-	} else {
-		if !pok && !dok {
-			p.Error(ErrUnknown)
-			return false
-		}
+	} else if !p.inPrefab && !pok && !dok {
+		p.Error(ErrUnknown)
+		return false
 	}
 
 	for {
@@ -295,10 +394,10 @@ func (p *Parser) element(isPrefab bool) bool {
 				return false
 			}
 
-			if pok {
-				prefab = p.prefabs[p.parsed.Name].create(p.parsed.Attributes)
-				for _, ch := range prefab.Children {
-					p.add(ch)
+			p.parsed.Span.End = p.End()
+			if pok && !p.inPrefab {
+				if !p.instantiate(prefab, &p.parsed) {
+					return false
 				}
 			} else {
 				p.add(p.parsed)
@@ -309,6 +408,7 @@ func (p *Parser) element(isPrefab bool) bool {
 			p.Error(ErrDiv.AfterIdent)
 			return false
 		}
+		p.open = false
 		return true
 	}
 }
@@ -318,7 +418,7 @@ func (p *Parser) attribute() bool {
 	if p.AdvanceOr(ErrDiv.Incomplete) {
 		return false
 	}
-	p.attribIdent = string(p.Ident())
+	p.attribIdent = p.intern(p.Ident())
 	switch p.Ch {
 	case '=':
 		return p.value()
@@ -343,17 +443,14 @@ func (p *Parser) value() bool {
 
 	switch p.Ch {
 	case '"':
-		if p.string('"', false) {
-			p.parsed.Attributes[p.attribIdent] = []string{string(p.stringBuff)}
+		if value, ok := p.string('"', false); ok {
+			p.parsed.Attributes[p.attribIdent] = []string{value}
 
 			if p.gs != nil && p.attribIdent == "style" {
-				p.styleBuff = p.styleBuff[:0]
-				for _, r := range p.stringBuff {
-					p.styleBuff = append(p.styleBuff, byte(r))
-				}
+				p.styleBuff = append(p.styleBuff[:0], value...)
 				style, err := p.gs.Style(p.styleBuff)
 				if err != nil {
-					p.Err = ErrStyle.Wrap(p.ReportError().Wrap(err))
+					p.wrapError(ErrStyle, err)
 					return false
 				}
 				p.parsed.Style = style
@@ -389,10 +486,11 @@ func (p *Parser) list() bool {
 				p.Error(ErrAttrib.ExtraSpace)
 				return false
 			case '"':
-				if !p.string('"', false) {
+				value, ok := p.string('"', false)
+				if !ok {
 					return false
 				}
-				list = append(list, string(p.stringBuff))
+				list = append(list, value)
 			case '{':
 				if p.AdvanceOr(ErrAttrib.Incomplete) {
 					return false
@@ -423,22 +521,77 @@ func (p *Parser) template(target *Element, idx int) bool {
 		return false
 	}
 
-	name := string(p.Ident())
-	if name == "" || p.Ch != '}' {
-		p.Error(ErrPrefab.Ident.Args(string(p.Ch)).Trace(4))
-		//panic(p.Err)
+	if p.inDefault {
+		p.Error(ErrPrefab.Default)
 		return false
 	}
 
-	target.prefabData = append(target.prefabData, prefabData{
+	pd := prefabData{
 		Target: p.attribIdent,
-		Name:   name,
+		Name:   p.intern(p.Ident()),
 		Idx:    idx,
-	})
+	}
+
+	switch p.Ch {
+	case '!':
+		pd.Required = true
+		if p.AdvanceOr(ErrAttrib.Incomplete) {
+			return false
+		}
+	case '=':
+		if !p.defaultValue(&pd) {
+			return false
+		}
+	}
+
+	if pd.Name == "" || p.Ch != '}' {
+		p.Error(ErrPrefab.Ident.Args(string(p.Ch)).Trace(4))
+		//panic(p.Err)
+		return false
+	}
+
+	target.prefabData = append(target.prefabData, pd)
 
 	return !p.AdvanceOr(ErrDiv.Incomplete)
 }
 
+// defaultValue parses default value of template parameter, p.Ch has to be '='
+func (p *Parser) defaultValue(pd *prefabData) bool {
+	if p.AdvanceOr(ErrAttrib.Incomplete) {
+		return false
+	}
+	if p.Ch != '"' {
+		p.Error(ErrPrefab.Default)
+		return false
+	}
+
+	// template can be inside a string so we cannot use p.stringBuff
+	buff := p.stringBuff
+	p.stringBuff = p.defaultBuff
+	p.inDefault = true
+	value, ok := p.string('"', false)
+	p.inDefault = false
+	p.defaultBuff = p.stringBuff
+	p.stringBuff = buff
+
+	pd.Default = value
+	pd.HasDefault = true
+	return ok
+}
+
+// instantiate creates prefab content for the use and adds it to current div
+func (p *Parser) instantiate(prefab Element, use *Element) bool {
+	res, err := p.expand(prefab, use, nil)
+	if err != nil {
+		p.Error(err.(sterr.Err))
+		return false
+	}
+	for _, ch := range res.Children {
+		p.add(ch)
+	}
+	return true
+}
+
 // add adds child to current div
 func (p *Parser) add(d Element) {
 	c := p.current()
@@ -506,6 +659,10 @@ type Element struct {
 	Attributes Attribs
 	Style      goss.Style
 	Children   []Element
+	// Span is the source range element was parsed from, elements
+	// created from prefab share the span of prefab usage
+	Span core.Span
+
 	prefabData []prefabData
 }
 
@@ -516,19 +673,97 @@ func NDiv() Element {
 	}
 }
 
-// Create creates template
-func (d Element) create(atr Attribs) Element {
+// expand checks whether use provides correct parameters and creates prefab, chain holds
+// names of prefabs that are being expanded so cycles can be detected
+func (p *Parser) expand(prefab Element, use *Element, chain []string) (Element, error) {
+	for _, name := range chain {
+		if name == use.Name {
+			return prefab, ErrPrefab.Cycle.Args(strings.Join(append(chain, use.Name), " -> "))
+		}
+	}
+
+	params := map[string]prefabData{}
+	prefab.params(params)
+	for name := range use.Attributes {
+		if _, ok := params[name]; !ok && name != SlotAttribute {
+			return prefab, ErrPrefab.Unexpected.Args(use.Name, name)
+		}
+	}
+	slots := map[string]bool{}
+	prefab.slots(slots)
+	for _, ch := range use.Children {
+		if name := ch.slotName(); !slots[name] {
+			return prefab, ErrPrefab.Slot.Args(use.Name, name)
+		}
+	}
+	for name, pd := range params {
+		if _, ok := use.Attributes[name]; !ok && pd.Required && use.slotContent(name) == nil {
+			return prefab, ErrPrefab.Missing.Args(use.Name, name)
+		}
+	}
+
+	return p.create(prefab, use, append(chain, use.Name))
+}
+
+// params collects all parameters of template, if parameter is used multiple times,
+// it is required if any of the usages is required and first default is used
+func (d Element) params(dst map[string]prefabData) {
+	for _, pd := range d.prefabData {
+		if prev, ok := dst[pd.Name]; ok {
+			prev.Required = prev.Required || pd.Required
+			if !prev.HasDefault {
+				prev.Default, prev.HasDefault = pd.Default, pd.HasDefault
+			}
+			pd = prev
+		}
+		dst[pd.Name] = pd
+	}
+	for _, ch := range d.Children {
+		ch.params(dst)
+	}
+}
+
+// create creates template, use is the element that uses prefab, its attributes are
+// substituted into parameters and its children are inserted into slots. Prefabs used
+// inside template are expanded too, chain holds names of prefabs that are being
+// expanded so cycles can be detected.
+func (p *Parser) create(d Element, use *Element, chain []string) (Element, error) {
+	atr := use.Attributes
+
 	// copy and create children
-	nch := make([]Element, len(d.Children))
-	for i := range nch {
-		nch[i] = d.Children[i].create(atr)
+	nch := make([]Element, 0, len(d.Children))
+	for _, ch := range d.Children {
+		if name, ok := ch.slot(); ok {
+			if content := use.slotContent(name); content != nil || name == ChildrenSlot {
+				nch = append(nch, content...)
+				continue
+			}
+		}
+
+		ch, err := p.create(ch, use, chain)
+		if err != nil {
+			return d, err
+		}
+
+		prefab, ok := p.prefab(ch.Name)
+		if !ok {
+			nch = append(nch, ch)
+			continue
+		}
+
+		ch, err = p.expand(prefab, &ch, chain)
+		if err != nil {
+			return d, err
+		}
+		nch = append(nch, ch.Children...)
 	}
 	d.Children = nch
+	d.Span = use.Span
 
-	// copy attributes
-	nat := make(Attribs, len(d.Attributes))
+	// copy attributes, values are copied as well because they can be modified
+	nat := attribPool.Get().(Attribs)
 	for k, v := range d.Attributes {
-		nat[k] = v
+		nat[k] = append([]string(nil), v...)
 	}
 	d.Attributes = nat
 
@@ -536,7 +771,10 @@ func (d Element) create(atr Attribs) Element {
 	for _, pd := range d.prefabData {
 		val, ok := atr[pd.Name]
 		if !ok {
-			continue
+			if !pd.HasDefault {
+				continue
+			}
+			val = []string{pd.Default}
 		}
 
 		// we are ignoring other values if supplied unless its a whole value
@@ -550,9 +788,102 @@ func (d Element) create(atr Attribs) Element {
 		}
 	}
 
+	return d, nil
+}
+
+// ChildrenSlot is the name of prefab parameter that is replaced by children
+// of prefab usage that do not specify a slot
+const ChildrenSlot = "children"
+
+// SlotAttribute is attribute that selects slot for child of prefab usage
+const SlotAttribute = "slot"
+
+// slot returns name of slot if element is a text that contains just one parameter
+func (d Element) slot() (string, bool) {
+	if d.Name != "text" || len(d.prefabData) != 1 {
+		return "", false
+	}
+	pd := d.prefabData[0]
+	return pd.Name, pd.Idx == stringTemplate && d.Attributes["text"][0] == "{"+pd.Name+"}"
+}
+
+// slots collects names of all slots in template
+func (d Element) slots(dst map[string]bool) {
+	for _, ch := range d.Children {
+		if name, ok := ch.slot(); ok {
+			dst[name] = true
+		}
+		ch.slots(dst)
+	}
+}
+
+// slotName returns name of slot child of prefab usage belongs to, children
+// without slot attribute belong to ChildrenSlot
+func (d Element) slotName() string {
+	slot, ok := d.Attributes[SlotAttribute]
+	if !ok {
+		return ChildrenSlot
+	}
+	if len(slot) == 0 {
+		return ""
+	}
+	return slot[0]
+}
+
+// slotContent returns children of d that belong to given slot, slot attribute is removed
+func (d Element) slotContent(name string) (content []Element) {
+	for _, ch := range d.Children {
+		if ch.slotName() != name {
+			continue
+		}
+		// each insertion gets its own copy so Release does not return
+		// one attribute map to pool twice
+		ch = ch.clone()
+		delete(ch.Attributes, SlotAttribute)
+		content = append(content, ch)
+	}
+	return
+}
+
+// clone returns deep copy of element, attribute maps are taken from pool
+func (d Element) clone() Element {
+	if d.Attributes != nil {
+		nat := attribPool.Get().(Attribs)
+		for k, v := range d.Attributes {
+			nat[k] = append([]string(nil), v...)
+		}
+		d.Attributes = nat
+	}
+	d.Style = cloneStyle(d.Style)
+	if d.Children != nil {
+		nch := make([]Element, len(d.Children))
+		for i, ch := range d.Children {
+			nch[i] = ch.clone()
+		}
+		d.Children = nch
+	}
 	return d
 }
 
+// cloneStyle returns deep copy of style
+func cloneStyle(s goss.Style) goss.Style {
+	if s == nil {
+		return nil
+	}
+	ns := make(goss.Style, len(s))
+	for k, values := range s {
+		nv := make([]interface{}, len(values))
+		for i, v := range values {
+			if sub, ok := v.(goss.Style); ok {
+				v = cloneStyle(sub)
+			}
+			nv[i] = v
+		}
+		ns[k] = nv
+	}
+	return ns
+}
+
 // prefabData related constants
 const (
 	wholeTemplate  = -1
@@ -563,6 +894,9 @@ const (
 type prefabData struct {
 	Name, Target string
 	Idx          int
+
+	Default              string
+	HasDefault, Required bool
 }
 
 type parser struct {
@@ -1,8 +1,10 @@
 package goml
 
 import (
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/jakubDoka/goml/core"
 	"github.com/jakubDoka/goml/goss"
@@ -33,7 +35,7 @@ func Test(t *testing.T) {
 		},
 	}
 
-	core.TestEqual(t, res, elem.Children)
+	core.TestEqual(t, res, noSpans(elem.Children))
 
 	elem, err = p.Parse([]byte(`<div style="a:f;k: 10;h: 10f;"/>`))
 	if err == nil {
@@ -43,6 +45,58 @@ func Test(t *testing.T) {
 
 type pr = map[string]Element
 
+// noSpans returns copy of elements with spans cleared so they can be compared with literals
+func noSpans(elems []Element) []Element {
+	if elems == nil {
+		return nil
+	}
+	res := make([]Element, len(elems))
+	for i, e := range elems {
+		e.Span = core.Span{}
+		e.Children = noSpans(e.Children)
+		res[i] = e
+	}
+	return res
+}
+
+// isKind returns whether err is parse error of given kind, zero kind expects nil
+func isKind(err error, kind sterr.Err) bool {
+	if err == nil {
+		return kind.SameSurface(nil)
+	}
+	return errors.Is(err, kind)
+}
+
+func TestSpans(t *testing.T) {
+	p := NParser(nil)
+	p.AddDefinitions("div", "button")
+	d, err := p.Parse([]byte("<#>\ncomment<#>\n<div>\n\thello\n\tthere \n\t<button/>\n</>\n<!b><button/><!/>\n<b/>"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	div := d.Children[0]
+	core.TestEqual(t, div.Span, core.Span{
+		Start: core.Pos{Offset: 15, Line: 2, Column: 0},
+		End:   core.Pos{Offset: 50, Line: 6, Column: 3},
+	})
+	core.TestEqual(t, div.Children[0].Span, core.Span{
+		Start: core.Pos{Offset: 22, Line: 3, Column: 1},
+		End:   core.Pos{Offset: 34, Line: 4, Column: 6},
+	})
+	core.TestEqual(t, div.Children[1].Span, core.Span{
+		Start: core.Pos{Offset: 37, Line: 5, Column: 1},
+		End:   core.Pos{Offset: 46, Line: 5, Column: 10},
+	})
+	// prefab content points to usage
+	core.TestEqual(t, d.Children[1].Span, core.Span{
+		Start: core.Pos{Offset: 69, Line: 8, Column: 0},
+		End:   core.Pos{Offset: 73, Line: 8, Column: 4},
+	})
+	core.TestEqual(t, d.Span.End, core.Pos{Offset: 73, Line: 8, Column: 4})
+}
+
 func TestShowcase(t *testing.T) {
 	p := NParser(nil)
 	p.AddDefinitions("div")
@@ -219,7 +273,7 @@ func TestPrefabGeneration(t *testing.T) {
 		t.Run(tC.desc, func(t *testing.T) {
 			p.ClearPrefabs()
 			div, err := p.Parse([]byte(tC.input))
-			if !tC.err.SameSurface(err) {
+			if !isKind(err, tC.err) {
 				t.Error(p.Err)
 				t.Error(string(p.Ch))
 				return
@@ -229,7 +283,7 @@ func TestPrefabGeneration(t *testing.T) {
 				return
 			}
 
-			core.TestEqual(t, div.Children, tC.output)
+			core.TestEqual(t, noSpans(div.Children), tC.output)
 
 		})
 	}
@@ -348,7 +402,7 @@ func TestPrefabDef(t *testing.T) {
 		t.Run(tC.desc, func(t *testing.T) {
 			p.ClearPrefabs()
 			_, err := p.Parse([]byte(tC.input))
-			if !tC.err.SameSurface(err) {
+			if !isKind(err, tC.err) {
 				t.Error(p.Err)
 				t.Error(sterr.ReadTrace(p.Err))
 				t.Error(string(p.Ch))
@@ -359,7 +413,11 @@ func TestPrefabDef(t *testing.T) {
 				return
 			}
 
-			if !reflect.DeepEqual(p.prefabs, tC.output) {
+			prefabs := pr{}
+			for k, v := range p.prefabs {
+				prefabs[k] = noSpans([]Element{v})[0]
+			}
+			if !reflect.DeepEqual(prefabs, tC.output) {
 				t.Error(p.prefabs)
 			}
 		})
@@ -466,7 +524,7 @@ func TestParse(t *testing.T) {
 		t.Run(tC.desc, func(t *testing.T) {
 			p.ClearPrefabs()
 			div, err := p.Parse([]byte(tC.input))
-			if !tC.err.SameSurface(err) {
+			if !isKind(err, tC.err) {
 				t.Error(p.Err)
 				t.Error(string(p.Ch), p.stack)
 				return
@@ -476,7 +534,7 @@ func TestParse(t *testing.T) {
 				return
 			}
 
-			if !reflect.DeepEqual(div.Children, tC.output) {
+			if !reflect.DeepEqual(noSpans(div.Children), tC.output) {
 				t.Error(div.Children, p.stack)
 			}
 		})
@@ -560,7 +618,7 @@ func TestDiv(t *testing.T) {
 				return
 			}
 
-			if !reflect.DeepEqual(p.root.Children, tC.output) && !reflect.DeepEqual([]Element(p.stack), tC.output) {
+			if !reflect.DeepEqual(noSpans(p.root.Children), tC.output) && !reflect.DeepEqual(noSpans(p.stack), tC.output) {
 				t.Error(p.root.Children, p.stack, tC.output)
 			}
 		})
@@ -820,6 +878,25 @@ func TestParseString(t *testing.T) {
 			input:  "a \\  b \t\"",
 			output: "a  b",
 		},
+		{
+			desc:   "text",
+			omit:   true,
+			ending: '<',
+			input:  "a b <",
+			output: "a b",
+		},
+		{
+			desc:   "text at the end",
+			omit:   true,
+			ending: '<',
+			input:  "a b ",
+			output: "a b",
+		},
+		{
+			desc:  "invalid utf8",
+			input: "a\xff\"",
+			err:   ErrInvalidRune,
+		},
 	}
 	for _, tC := range testCases {
 		t.Run(tC.desc, func(t *testing.T) {
@@ -828,7 +905,7 @@ func TestParseString(t *testing.T) {
 			if tC.ending == 0 {
 				tC.ending = '"'
 			}
-			p.string(tC.ending, tC.omit)
+			res, _ := p.string(tC.ending, tC.omit)
 			if !tC.err.SameSurface(p.Err) {
 				t.Error(p.Err)
 				return
@@ -838,10 +915,426 @@ func TestParseString(t *testing.T) {
 				return
 			}
 
-			res := string(p.stringBuff)
 			if res != tC.output {
 				t.Errorf("%q != %q || %v != %v", res, tC.output, res, tC.output)
 			}
 		})
 	}
 }
+
+func TestParseRecover(t *testing.T) {
+	p := NParser(nil)
+	p.AddDefinitions("div", "button")
+	p.AddPrefabs([]byte(`<!b><button/><!/>`))
+	d, diags := p.ParseRecover([]byte(`
+<div h,"f">
+	<button a=[ "b"]/>
+	hello \q there
+	<fiv/>
+	<b c=/>
+	<button/>
+</>
+</>
+<div>`))
+
+	errs := []sterr.Err{
+		ErrAttrib.Assignmant,
+		ErrAttrib.ExtraSpace,
+		ErrEscape.InvalidIdent,
+		ErrUnknown,
+		ErrAttrib.ValueStart,
+		ErrDiv.ExtraClosure,
+		ErrDiv.MissingClosure,
+	}
+	if len(diags) != len(errs) {
+		t.Error(diags)
+		return
+	}
+	for i, e := range errs {
+		if !isKind(diags[i].Err, e) {
+			t.Error(i, diags[i])
+		}
+	}
+
+	core.TestEqual(t, diags[0].Pos, core.Pos{Offset: 7, Line: 1, Column: 6})
+
+	core.TestEqual(t, noSpans(d.Children), []Element{
+		{
+			Name:       "div",
+			Attributes: Attribs{},
+			Children: []Element{
+				{
+					Name:       "button",
+					Attributes: Attribs{},
+				},
+				{
+					Name:       "fiv",
+					Attributes: Attribs{},
+				},
+				{
+					Name:       "button",
+					Attributes: Attribs{},
+				},
+			},
+		},
+		{
+			Name:       "div",
+			Attributes: Attribs{},
+		},
+	})
+}
+
+func TestParseRecoverTrailing(t *testing.T) {
+	testCases := []struct {
+		desc, input string
+		children    int
+	}{
+		{
+			desc:  "lone",
+			input: "<",
+		},
+		{
+			desc:     "after element",
+			input:    "<div/><",
+			children: 1,
+		},
+		{
+			desc:     "after text",
+			input:    "<div>text</><",
+			children: 1,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			p := NParser(nil)
+			p.AddDefinitions("div")
+
+			done := make(chan struct{})
+			var (
+				d     Element
+				diags []Diagnostic
+			)
+			go func() {
+				d, diags = p.ParseRecover([]byte(tC.input))
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("ParseRecover did not return")
+			}
+
+			if len(diags) != 1 || !isKind(diags[0].Err, ErrDiv.Incomplete) {
+				t.Error(diags)
+			}
+			if len(d.Children) != tC.children {
+				t.Error(d.Children)
+			}
+		})
+	}
+}
+
+func TestPrefabSlots(t *testing.T) {
+	p := NParser(nil)
+	p.AddDefinitions("div", "button")
+	d, err := p.Parse([]byte(`
+<!card>
+	<div class="card">
+		<div class="header">{header}</>
+		{children}
+	</>
+<!/>
+
+<card>
+	<button slot="header"/>
+	hello
+	<button/>
+	<button slot="children" id="a"/>
+</>
+<card header="text"/>
+	`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	core.TestEqual(t, noPrefabData(noSpans(d.Children)), []Element{
+		{
+			Name:       "div",
+			Attributes: Attribs{"class": {"card"}},
+			Children: []Element{
+				{
+					Name:       "div",
+					Attributes: Attribs{"class": {"header"}},
+					Children: []Element{
+						{
+							Name:       "button",
+							Attributes: Attribs{},
+						},
+					},
+				},
+				{
+					Name:       "text",
+					Attributes: Attribs{"text": {"hello"}},
+				},
+				{
+					Name:       "button",
+					Attributes: Attribs{},
+				},
+				{
+					Name:       "button",
+					Attributes: Attribs{"id": {"a"}},
+				},
+			},
+		},
+		{
+			Name:       "div",
+			Attributes: Attribs{"class": {"card"}},
+			Children: []Element{
+				{
+					Name:       "div",
+					Attributes: Attribs{"class": {"header"}},
+					Children: []Element{
+						{
+							Name:       "text",
+							Attributes: Attribs{"text": {"text"}},
+							Children:   []Element{},
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestPrefabUnknownSlot(t *testing.T) {
+	p := NParser(nil)
+	p.AddDefinitions("div")
+	err := p.AddPrefabs([]byte(`<!card><div class={kind}>{header}</><!/>`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	testCases := []struct {
+		desc, input string
+	}{
+		{"undeclared", `<card><div slot="footer"/></>`},
+		{"attribute parameter", `<card><div slot="kind"/></>`},
+		{"empty", `<card><div slot=""/></>`},
+		{"children without placeholder", `<card><div/></>`},
+		{"children slot without placeholder", `<card><div slot="children"/></>`},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			_, err := p.Parse([]byte(tC.input))
+			if !isKind(err, ErrPrefab.Slot) {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// noPrefabData clears prefab data from elements
+func noPrefabData(elems []Element) []Element {
+	for i := range elems {
+		elems[i].prefabData = nil
+		elems[i].Children = noPrefabData(elems[i].Children)
+	}
+	return elems
+}
+
+func TestNestedPrefabs(t *testing.T) {
+	p := NParser(nil)
+	p.AddDefinitions("div", "button")
+	d, err := p.Parse([]byte(`
+<!dialog>
+	<div>
+		<yes_no yes={on_yes} no="close"/>
+		<wrap>{children}</>
+	</>
+<!/>
+<!yes_no>
+	<button onclick={yes}/>
+	<button onclick={no}/>
+<!/>
+<!wrap><div>{children}</><!/>
+
+<dialog on_yes="accept">hello</>
+	`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	core.TestEqual(t, noPrefabData(noSpans(d.Children)), []Element{
+		{
+			Name:       "div",
+			Attributes: Attribs{},
+			Children: []Element{
+				{
+					Name:       "button",
+					Attributes: Attribs{"onclick": {"accept"}},
+					Children:   []Element{},
+				},
+				{
+					Name:       "button",
+					Attributes: Attribs{"onclick": {"close"}},
+					Children:   []Element{},
+				},
+				{
+					Name:       "div",
+					Attributes: Attribs{},
+					Children: []Element{
+						{
+							Name:       "text",
+							Attributes: Attribs{"text": {"hello"}},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	p.ClearPrefabs()
+	_, err = p.Parse([]byte(`
+<!a><div><b/></><!/>
+<!b><a/><!/>
+<a/>
+	`))
+	if !errors.Is(err, ErrPrefab.Cycle) {
+		t.Error(err)
+	}
+}
+
+func TestPrefabParams(t *testing.T) {
+	p := NParser(nil)
+	p.AddDefinitions("div", "button")
+	err := p.AddPrefabs([]byte(`
+<!btn>
+	<button label={label="OK"} action={action!} class=["btn" {kind="plain"}]>
+		{label="OK"} {icon="<\"x\">"}
+	</>
+<!/>
+	`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	d, err := p.Parse([]byte(`<btn action="submit" kind="main"/>`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	core.TestEqual(t, noPrefabData(noSpans(d.Children)), []Element{
+		{
+			Name: "button",
+			Attributes: Attribs{
+				"label":  {"OK"},
+				"action": {"submit"},
+				"class":  {"btn", "main"},
+			},
+			Children: []Element{
+				{
+					Name:       "text",
+					Attributes: Attribs{"text": {`OK <"x">`}},
+					Children:   []Element{},
+				},
+			},
+		},
+	})
+
+	testCases := []struct {
+		desc, input string
+		err         sterr.Err
+	}{
+		{
+			desc:  "missing",
+			input: `<btn/>`,
+			err:   ErrPrefab.Missing,
+		},
+		{
+			desc:  "unexpected",
+			input: `<btn action="a" onclik="b"/>`,
+			err:   ErrPrefab.Unexpected,
+		},
+		{
+			desc:  "default with parameter",
+			input: `<!a><div a={a="{b}"}/><!/>`,
+			err:   ErrPrefab.Default,
+		},
+		{
+			desc:  "default not a string",
+			input: `<!a><div a={a=b}/><!/>`,
+			err:   ErrPrefab.Default,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			_, err := p.Parse([]byte(tC.input))
+			if !isKind(err, tC.err) {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestStyleNonASCII(t *testing.T) {
+	p := NParser(&goss.Parser{})
+	p.AddDefinitions("div")
+	// runes used to be truncated to bytes, 'š' turned into valid 'a'
+	if _, err := p.Parse([]byte(`<div style="x: š;"/>`)); !errors.Is(err, ErrStyle) {
+		t.Error(err)
+	}
+}
+
+func TestComments(t *testing.T) {
+	testCases := []struct {
+		desc, input string
+		start       core.Pos
+		err         sterr.Err
+	}{
+		{
+			// comment end used to consume one extra byte
+			desc:  "followed by element",
+			input: "<#>c<#><div/>",
+			start: core.Pos{Offset: 7, Line: 0, Column: 7},
+		},
+		{
+			// comment end that ends source used to be reported as not closed
+			desc:  "at end of source",
+			input: "<div/><#>c<#>",
+			start: core.Pos{Offset: 0, Line: 0, Column: 0},
+		},
+		{
+			desc:  "lines",
+			input: "<#>a\nb<#>\n<div/>",
+			start: core.Pos{Offset: 10, Line: 2, Column: 0},
+		},
+		{
+			desc:  "not closed",
+			input: "<div/><#>c<#",
+			err:   ErrComment.NotClosed,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			p := NParser(nil)
+			p.AddDefinitions("div")
+			d, err := p.Parse([]byte(tC.input))
+			if !isKind(err, tC.err) {
+				t.Error(err)
+			}
+			if err != nil {
+				return
+			}
+			if len(d.Children) != 1 {
+				t.Error(d.Children)
+				return
+			}
+			core.TestEqual(t, d.Children[0].Span.Start, tC.start)
+		})
+	}
+}
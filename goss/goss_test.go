@@ -1,6 +1,7 @@
 package goss
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/jakubDoka/goml/core"
@@ -219,3 +220,57 @@ func TestStyle(t *testing.T) {
 		t.Error("no uint")
 	}
 }
+
+func TestStyleString(t *testing.T) {
+	s := Style{
+		"b": {10, -1},
+		"c": {float64(11), 1.5},
+		"e": {"hello"},
+		"d": {"kl", Style{"a": {"b"}}},
+	}
+
+	str := s.String()
+	if str != "b: 10 -1; c: 11f 1.5f; d: kl {a: b;}; e: hello;" {
+		t.Error(str)
+	}
+
+	p := Parser{}
+	res, err := p.Style([]byte(str))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	core.TestEqual(t, res, s)
+}
+
+func TestStyleJSON(t *testing.T) {
+	s := Style{
+		"a": {10, uint64(1) << 63, float64(2), "left"},
+		"b": {Style{"c": {1.5}}, Style{}},
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(data) != `{"a":[{"int":10},{"uint":9223372036854775808},{"float":2},{"ident":"left"}],"b":[{"style":{"c":[{"float":1.5}]}},{"style":{}}]}` {
+		t.Error(string(data))
+	}
+
+	var res Style
+	if err := json.Unmarshal(data, &res); err != nil {
+		t.Error(err)
+		return
+	}
+	core.TestEqual(t, res, s)
+
+	if err := json.Unmarshal([]byte(`{"a":[{"int":1,"float":1}]}`), &res); !ErrJSONValue.SameSurface(err) {
+		t.Error(err)
+	}
+
+	if _, err := (Style{"a": {true}}).MarshalJSON(); !ErrJSONType.SameSurface(err) {
+		t.Error(err)
+	}
+}
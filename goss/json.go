@@ -0,0 +1,100 @@
+package goss
+
+import (
+	"encoding/json"
+
+	"github.com/jakubDoka/sterr"
+)
+
+// ErrJSONValue is returned when json value of style property is not valid
+var ErrJSONValue = sterr.New("style value has to have exactly one of int, uint, float, ident or style set, got %s")
+
+// ErrJSONType is returned when style holds value that has no json representation
+var ErrJSONType = sterr.New("style value of type %T cannot be encoded into json")
+
+// jsonValue is json representation of one style value, exactly one field is set
+type jsonValue struct {
+	Int   *int     `json:"int,omitempty"`
+	Uint  *uint64  `json:"uint,omitempty"`
+	Float *float64 `json:"float,omitempty"`
+	Ident *string  `json:"ident,omitempty"`
+	Style *Style   `json:"style,omitempty"`
+}
+
+// MarshalJSON encodes style as object of property names mapped to lists of
+// values. Each value is an object with single field naming its type so number
+// types survive the round trip, uint64 values of styles built in code are
+// encoded as {"uint": 10}:
+//
+//	{"margin": [{"int": 10}, {"float": 1.5}], "align": [{"ident": "left"}], "hover": [{"style": {...}}]}
+func (s Style) MarshalJSON() ([]byte, error) {
+	m := make(map[string][]jsonValue, len(s))
+	for k, values := range s {
+		jv := make([]jsonValue, len(values))
+		for i, v := range values {
+			switch v := v.(type) {
+			case int:
+				jv[i].Int = &v
+			case uint64:
+				jv[i].Uint = &v
+			case float64:
+				jv[i].Float = &v
+			case string:
+				jv[i].Ident = &v
+			case Style:
+				jv[i].Style = &v
+			default:
+				return nil, ErrJSONType.Args(v)
+			}
+		}
+		m[k] = jv
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON decodes style from format described in MarshalJSON
+func (s *Style) UnmarshalJSON(data []byte) error {
+	var m map[string][]jsonValue
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	if m == nil {
+		*s = nil
+		return nil
+	}
+
+	stl := make(Style, len(m))
+	for k, jv := range m {
+		values := make([]interface{}, len(jv))
+		for i, v := range jv {
+			set := 0
+			if v.Int != nil {
+				values[i] = *v.Int
+				set++
+			}
+			if v.Uint != nil {
+				values[i] = *v.Uint
+				set++
+			}
+			if v.Float != nil {
+				values[i] = *v.Float
+				set++
+			}
+			if v.Ident != nil {
+				values[i] = *v.Ident
+				set++
+			}
+			if v.Style != nil {
+				values[i] = *v.Style
+				set++
+			}
+			if set != 1 {
+				raw, _ := json.Marshal(v)
+				return ErrJSONValue.Args(raw)
+			}
+		}
+		stl[k] = values
+	}
+	*s = stl
+	return nil
+}
@@ -0,0 +1,57 @@
+package goss
+
+import (
+	"sort"
+	"strconv"
+)
+
+// String returns style in goss syntax as it would be written in goml style attribute,
+// properties are sorted so output is deterministic
+func (s Style) String() string {
+	return string(s.AppendTo(nil))
+}
+
+// AppendTo appends style in goss syntax to buff and returns extended buffer
+func (s Style) AppendTo(buff []byte) []byte {
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for i, k := range keys {
+		if i != 0 {
+			buff = append(buff, ' ')
+		}
+		buff = append(buff, k...)
+		buff = append(buff, ':')
+		for _, v := range s[k] {
+			buff = append(buff, ' ')
+			buff = AppendValue(buff, v)
+		}
+		buff = append(buff, ';')
+	}
+
+	return buff
+}
+
+// AppendValue appends one property value in goss syntax, floats always get 'f'
+// suffix so they are not parsed as integers
+func AppendValue(buff []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case Style:
+		buff = append(buff, '{')
+		buff = v.AppendTo(buff)
+		buff = append(buff, '}')
+	case int:
+		buff = strconv.AppendInt(buff, int64(v), 10)
+	case uint64:
+		buff = strconv.AppendUint(buff, v, 10)
+	case float64:
+		buff = strconv.AppendFloat(buff, v, 'f', -1, 64)
+		buff = append(buff, 'f')
+	case string:
+		buff = append(buff, v...)
+	}
+	return buff
+}
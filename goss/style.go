@@ -14,7 +14,9 @@ func (s Styles) Add(o Styles) {
 	}
 }
 
-// Style is a parsed form of goss syntax
+// Style is a parsed form of goss syntax. Values are int, float64, string or
+// Style, styles built in code can also hold uint64 which is printed, encoded
+// into json and generated as Go code, though parser never produces it.
 type Style map[string][]interface{}
 
 // Sub returns substyle within style
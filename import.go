@@ -0,0 +1,198 @@
+package goml
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/jakubDoka/sterr"
+)
+
+// ErrImport contains import directive related errors
+var ErrImport = struct {
+	Directive, Syntax, Nested, NoFS, Path, Read, Cycle, Content, Failed sterr.Err
+}{
+	sterr.New("unknown directive '%s'"),
+	sterr.New("import has to be written as <@import \"file.goml\">"),
+	sterr.New("imports have to be on top level"),
+	sterr.New("parser has no file system to import from, use Parser.SetFS"),
+	sterr.New("invalid import path '%s'"),
+	sterr.New("cannot read '%s'"),
+	sterr.New("import cycle %s"),
+	sterr.New("imported file can contain only prefab definitions and imports"),
+	sterr.New("error in imported file '%s'"),
+}
+
+// SetFS sets file system that imports and ParseFile read from
+func (p *Parser) SetFS(fsys fs.FS) {
+	p.fsys = fsys
+}
+
+// ParseFile reads file from file system set by SetFS and parses it, imports in
+// the file are relative to its directory and returned ParseError has File set.
+// Successfully parsed file counts as imported so its prefabs are not defined
+// again by later imports.
+func (p *Parser) ParseFile(name string) (Element, error) {
+	if p.fsys == nil {
+		return NDiv(), ErrImport.NoFS
+	}
+	src, err := fs.ReadFile(p.fsys, name)
+	if err != nil {
+		return NDiv(), err
+	}
+	p.file = path.Clean(name)
+	defer func() { p.file = "" }()
+	root, err := p.Parse(src)
+	if err == nil {
+		if p.imported == nil {
+			p.imported = map[string]bool{}
+		}
+		p.imported[p.file] = true
+	}
+	return root, err
+}
+
+// ParseRecoverFile is like ParseRecover but imports in src are resolved relative
+// to name, source is not read from file system so it can differ from the file,
+// for example when it is edited
+func (p *Parser) ParseRecoverFile(name string, src []byte) (Element, []Diagnostic) {
+	p.file = path.Clean(name)
+	defer func() { p.file = "" }()
+	return p.ParseRecover(src)
+}
+
+// directive parses directive after '<@', only import is supported:
+//
+//	<@import "widgets.goml">
+//
+// Path is relative to the file being parsed, paths starting with '/' are relative
+// to the root of file system. Each file is imported only once, later imports
+// of the same file are ignored. Imported file can contain only prefab definitions
+// and other imports. When imported file fails, none of its prefabs are defined.
+func (p *Parser) directive() bool {
+	if p.AdvanceOr(ErrDiv.Incomplete) {
+		return false
+	}
+	if name := string(p.Ident()); name != "import" {
+		p.Error(ErrImport.Directive.Args(name))
+		return false
+	}
+	if p.Ch != ' ' || p.AdvanceOr(ErrImport.Syntax) || p.Ch != '"' {
+		p.Error(ErrImport.Syntax)
+		return false
+	}
+	target, ok := p.string('"', false)
+	if !ok {
+		return false
+	}
+	if p.Ch != '>' {
+		p.Error(ErrImport.Syntax)
+		return false
+	}
+	if len(p.stack) != 0 || p.inPrefab {
+		p.Error(ErrImport.Nested)
+		return false
+	}
+
+	return p.importFile(target)
+}
+
+// importFile parses imported file with parser that shares definitions and prefabs
+func (p *Parser) importFile(target string) bool {
+	if p.fsys == nil {
+		p.Error(ErrImport.NoFS)
+		return false
+	}
+
+	name := strings.TrimPrefix(target, "/")
+	if name == target {
+		name = path.Join(path.Dir(p.file), name)
+	}
+	name = path.Clean(name)
+	if !fs.ValidPath(name) {
+		p.Error(ErrImport.Path.Args(target))
+		return false
+	}
+
+	chain := p.importing
+	if p.file != "" {
+		chain = append(append([]string(nil), chain...), p.file)
+	}
+	for _, f := range chain {
+		if f == name {
+			p.Error(ErrImport.Cycle.Args(strings.Join(append(chain, name), " -> ")))
+			return false
+		}
+	}
+
+	if p.isImported(name) {
+		return true
+	}
+
+	src, err := fs.ReadFile(p.fsys, name)
+	if err != nil {
+		p.wrapError(ErrImport.Read.Args(name), err)
+		return false
+	}
+
+	if p.imported == nil {
+		p.imported = map[string]bool{}
+	}
+	if p.origins == nil {
+		p.origins = map[string]string{}
+	}
+	child := &Parser{
+		gs:        p.gs,
+		defined:   p.defined,
+		schemas:   p.schemas,
+		prefabs:   p.prefabs,
+		registry:  p.registry,
+		names:     p.names,
+		fsys:      p.fsys,
+		imported:  p.imported,
+		origins:   p.origins,
+		file:      name,
+		importing: chain,
+	}
+	prefabs := make(map[string]bool, len(p.prefabs))
+	for name := range p.prefabs {
+		prefabs[name] = true
+	}
+	imported := make(map[string]bool, len(p.imported))
+	for name := range p.imported {
+		imported[name] = true
+	}
+	root, err := child.Parse(src)
+	if err == nil && len(root.Children) != 0 {
+		child.ErrPos = root.Children[0].Span.Start
+		child.ErrKind = ErrImport.Content
+		pe := child.newParseError()
+		err = pe
+	}
+	if err != nil {
+		// nothing from failed file stays so it can be imported again once fixed
+		for name := range p.prefabs {
+			if !prefabs[name] {
+				p.RemovePrefabs(name)
+			}
+		}
+		for name := range p.imported {
+			if !imported[name] {
+				delete(p.imported, name)
+			}
+		}
+		pe := err.(*ParseError)
+		pe.Imports = append(pe.Imports, p.location(p.start))
+		p.importErr = pe
+		p.wrapError(ErrImport.Failed.Args(name), err)
+		return false
+	}
+
+	p.imported[name] = true
+	return true
+}
+
+// isImported returns whether file was already imported by parser or registry
+func (p *Parser) isImported(name string) bool {
+	return p.imported[name] || p.registry != nil && p.registry.imported[name]
+}
@@ -0,0 +1,183 @@
+package goml
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jakubDoka/sterr"
+)
+
+func TestImport(t *testing.T) {
+	fsys := fstest.MapFS{
+		"widgets/button.goml": {Data: []byte(`<@import "../base.goml"><!btn><div text={text}/><!/>`)},
+		"widgets/list.goml":   {Data: []byte(`<@import "/base.goml"><@import "button.goml"><!list><btn text="a"/><!/>`)},
+		"base.goml":           {Data: []byte(`<#> shared <#><!base><div/><!/>`)},
+		"cycle/a.goml":        {Data: []byte(`<@import "b.goml">`)},
+		"cycle/b.goml":        {Data: []byte(`<@import "a.goml">`)},
+		"content.goml":        {Data: []byte("<!a><div/><!/>\n<div/>")},
+		"broken.goml":         {Data: []byte(`<@import "content.goml">`)},
+		"main.goml":           {Data: []byte(`<@import "widgets/list.goml"><list/>`)},
+	}
+
+	p := NParser(nil)
+	p.AddDefinitions("div")
+	p.SetFS(fsys)
+	root, err := p.ParseFile("main.goml")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(root.Children) != 1 || root.Children[0].Attributes.Ident("text", "") != "a" {
+		t.Error(root)
+	}
+	if !p.imported["main.goml"] {
+		t.Error("main.goml is not marked as imported")
+	}
+	for _, name := range []string{"base", "btn", "list"} {
+		if _, ok := p.prefabs[name]; !ok {
+			t.Error(name)
+		}
+	}
+
+	testCases := []struct {
+		desc, input string
+		err         sterr.Err
+	}{
+		{
+			desc:  "already imported",
+			input: `<@import "base.goml"><base/>`,
+		},
+		{
+			desc:  "cycle",
+			input: `<@import "cycle/a.goml">`,
+			err:   ErrImport.Cycle,
+		},
+		{
+			desc:  "content",
+			input: `<@import "broken.goml">`,
+			err:   ErrImport.Content,
+		},
+		{
+			desc:  "missing",
+			input: `<@import "missing.goml">`,
+			err:   ErrImport.Read,
+		},
+		{
+			desc:  "path",
+			input: `<@import "../base.goml">`,
+			err:   ErrImport.Path,
+		},
+		{
+			desc:  "nested",
+			input: `<div><@import "base.goml"></>`,
+			err:   ErrImport.Nested,
+		},
+		{
+			desc:  "syntax",
+			input: `<@import "base.goml"/>`,
+			err:   ErrImport.Syntax,
+		},
+		{
+			desc:  "directive",
+			input: `<@include "base.goml">`,
+			err:   ErrImport.Directive,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			_, err := p.Parse([]byte(tC.input))
+			if !isKind(err, tC.err) {
+				t.Error(err)
+			}
+		})
+	}
+
+	p.ClearPrefabs()
+	_, err = p.ParseFile("broken.goml")
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Error(err)
+		return
+	}
+	if pe.File != "content.goml" || pe.Line != 2 || pe.Column != 1 {
+		t.Error(pe.File, pe.Line, pe.Column)
+	}
+	if err.Error() != "content.goml:2:1: "+ErrImport.Content.Error()+", imported at broken.goml:1:1" {
+		t.Error(err)
+	}
+
+	_, diagnostics := p.ParseRecoverFile("widgets/page.goml", []byte(`<@import "button.goml"><@import "missing.goml"><btn text="a"/>`))
+	if len(diagnostics) != 1 || !isKind(diagnostics[0].Err, ErrImport.Read) {
+		t.Error(diagnostics)
+	}
+
+	_, err = NParser(nil).Parse([]byte(`<@import "base.goml">`))
+	if !isKind(err, ErrImport.NoFS) {
+		t.Error(err)
+	}
+}
+
+func TestParseFileImported(t *testing.T) {
+	fsys := fstest.MapFS{
+		"base.goml":   {Data: []byte(`<!base><div/><!/>`)},
+		"uses.goml":   {Data: []byte(`<@import "base.goml"><base/>`)},
+		"broken.goml": {Data: []byte(`<!broken><div><!/>`)},
+	}
+
+	p := NParser(nil)
+	p.AddDefinitions("div")
+	p.SetFS(fsys)
+	if _, err := p.ParseFile("base.goml"); err != nil {
+		t.Error(err)
+		return
+	}
+	r := p.Freeze()
+	if !r.Imported("base.goml") || !r.Imported("./base.goml") || r.Imported("uses.goml") {
+		t.Error(r.imported)
+	}
+	// base.goml is not parsed again, that would shadow its prefab
+	if _, err := p.ParseFile("uses.goml"); err != nil {
+		t.Error(err)
+	}
+	if _, err := r.ParseFile("uses.goml"); err != nil {
+		t.Error(err)
+	}
+
+	// failed file is not marked, so it can be parsed again once fixed
+	if _, err := p.ParseFile("broken.goml"); err == nil {
+		t.Error("expected error")
+	}
+	if p.imported["broken.goml"] {
+		t.Error("broken.goml is marked as imported")
+	}
+}
+
+func TestImportRetry(t *testing.T) {
+	fsys := fstest.MapFS{
+		"base.goml": {Data: []byte(`<!base><div/><!/>`)},
+		"lib.goml":  {Data: []byte(`<@import "base.goml"><!lib><div/><!/><!broken><div><!/>`)},
+	}
+
+	p := NParser(nil)
+	p.AddDefinitions("div")
+	p.SetFS(fsys)
+	if _, err := p.Parse([]byte(`<@import "lib.goml">`)); err == nil {
+		t.Error("expected error")
+		return
+	}
+	if _, ok := p.prefabs["lib"]; ok || p.imported["base.goml"] {
+		t.Error("prefabs and imports of failed file have to be dropped")
+	}
+
+	// fixed file is imported without shadowing prefabs from failed attempt
+	fsys["lib.goml"] = &fstest.MapFile{Data: []byte(`<@import "base.goml"><!lib><div/><!/>`)}
+	root, err := p.Parse([]byte(`<@import "lib.goml"><lib/><base/>`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(root.Children) != 2 {
+		t.Error(root)
+	}
+}
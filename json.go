@@ -0,0 +1,89 @@
+package goml
+
+import (
+	"encoding/json"
+
+	"github.com/jakubDoka/goml/core"
+	"github.com/jakubDoka/goml/goss"
+)
+
+// jsonElement is json representation of Element
+type jsonElement struct {
+	Name       string      `json:"name"`
+	Attributes Attribs     `json:"attributes,omitempty"`
+	Style      goss.Style  `json:"style,omitempty"`
+	Children   []Element   `json:"children,omitempty"`
+	Span       *core.Span  `json:"span,omitempty"`
+	Params     []jsonParam `json:"params,omitempty"`
+}
+
+// jsonParam is json representation of prefab parameter
+type jsonParam struct {
+	Name       string `json:"name"`
+	Target     string `json:"target"`
+	Idx        int    `json:"index"`
+	Default    string `json:"default,omitempty"`
+	HasDefault bool   `json:"hasDefault,omitempty"`
+	Required   bool   `json:"required,omitempty"`
+}
+
+// MarshalJSON encodes element as:
+//
+//	{
+//		"name": "div",
+//		"attributes": {"class": ["a", "b"]},
+//		"style": {"margin": [{"int": 10}]},
+//		"children": [...],
+//		"span": {"start": {"offset": 0, "line": 0, "column": 0}, "end": {...}},
+//		"params": [{"name": "p", "target": "class", "index": 1}]
+//	}
+//
+// Empty fields are omitted, span is omitted when it is zero. Style values are
+// encoded as described in goss.Style.MarshalJSON. Params are present only in
+// prefab definitions, index -1 means parameter is the whole attribute and -2
+// means it is placed inside text.
+func (e Element) MarshalJSON() ([]byte, error) {
+	je := jsonElement{
+		Name:     e.Name,
+		Style:    e.Style,
+		Children: e.Children,
+	}
+	if len(e.Attributes) != 0 {
+		je.Attributes = e.Attributes
+	}
+	if e.Span != (core.Span{}) {
+		je.Span = &e.Span
+	}
+	for _, pd := range e.prefabData {
+		je.Params = append(je.Params, jsonParam(pd))
+	}
+	return json.Marshal(je)
+}
+
+// UnmarshalJSON decodes element from format described in MarshalJSON. Attributes
+// are never nil after decoding, same as in elements produced by parser. Empty
+// children are omitted by MarshalJSON so they decode as nil, even though
+// elements created from prefabs have empty non-nil Children.
+func (e *Element) UnmarshalJSON(data []byte) error {
+	var je jsonElement
+	if err := json.Unmarshal(data, &je); err != nil {
+		return err
+	}
+
+	*e = Element{
+		Name:       je.Name,
+		Attributes: je.Attributes,
+		Style:      je.Style,
+		Children:   je.Children,
+	}
+	if e.Attributes == nil {
+		e.Attributes = Attribs{}
+	}
+	if je.Span != nil {
+		e.Span = *je.Span
+	}
+	for _, jp := range je.Params {
+		e.prefabData = append(e.prefabData, prefabData(jp))
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package goml
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/jakubDoka/goml/goss"
+)
+
+func TestJSON(t *testing.T) {
+	p := NParser(&goss.Parser{})
+	p.AddDefinitions("div")
+	err := p.AddPrefabs([]byte(`<!a><div h={h} m=["a" {j!}] s="hi {x="y"}"/><!/>`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	root, err := p.Parse([]byte(`<div class=["a" "b"] style="a: 1 2f x; b: {{c: d;};">text<div/><a j="k"/></>`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	for _, e := range []Element{root, p.prefabs["a"]} {
+		data, err := json.Marshal(e)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		var res Element
+		if err := json.Unmarshal(data, &res); err != nil {
+			t.Error(err)
+			return
+		}
+		// empty children of expanded prefab are not encoded
+		expected := noEmptyChildren([]Element{e})[0]
+		if !reflect.DeepEqual(res, expected) {
+			t.Errorf("%#v\n%#v", res, expected)
+		}
+	}
+
+	if _, err := json.Marshal(Element{Style: goss.Style{"a": {true}}}); err == nil {
+		t.Error("style with bool encoded")
+	}
+
+	data, err := json.Marshal(Element{Name: "div", Attributes: Attribs{"a": {"b"}}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(data) != `{"name":"div","attributes":{"a":["b"]}}` {
+		t.Error(string(data))
+	}
+}
+
+// noEmptyChildren replaces empty children with nil
+func noEmptyChildren(elems []Element) []Element {
+	if len(elems) == 0 {
+		return nil
+	}
+	res := make([]Element, len(elems))
+	for i, e := range elems {
+		e.Children = noEmptyChildren(e.Children)
+		res[i] = e
+	}
+	return res
+}
@@ -0,0 +1,243 @@
+package goml
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/jakubDoka/goml/goss"
+	"github.com/jakubDoka/sterr"
+)
+
+// ErrMarshal stores errors returned by Marshal
+var ErrMarshal = struct {
+	Target, Unsupported, Value, Child sterr.Err
+}{
+	sterr.New("value has to be struct, pointer to struct or slice of them, got %s"),
+	sterr.New("field '%s' has unsupported type %s"),
+	sterr.New("field '%s' of element '%s' cannot be encoded from %s"),
+	sterr.New("cannot encode child '%s' of element '%s'"),
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// Marshal encodes struct into element with given name, it is inverse of Unmarshal and
+// uses same tags. Additional "omitempty" flag omits attributes with zero value and
+// nil pointers, including items of slices, are always omitted. If v is a slice, root
+// element with no name is returned and each item of slice becomes its child. Nil
+// pointers and Elements without name are not encoded as children.
+func Marshal(name string, v interface{}) (Element, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice {
+		root := NDiv()
+		for i := 0; i < rv.Len(); i++ {
+			if absent(rv.Index(i)) {
+				continue
+			}
+			ch, err := marshalValue(name, rv.Index(i))
+			if err != nil {
+				return root, err
+			}
+			root.Children = append(root.Children, ch)
+		}
+		return root, nil
+	}
+	return marshalValue(name, rv)
+}
+
+func marshalValue(name string, rv reflect.Value) (Element, error) {
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		if !rv.IsValid() {
+			return Element{}, ErrMarshal.Target.Args(nil)
+		}
+		return Element{}, ErrMarshal.Target.Args(rv.Type())
+	}
+	if rv.Type() == elementType {
+		e := rv.Interface().(Element)
+		e.Name = name
+		return e, nil
+	}
+
+	e := NDiv()
+	e.Name = name
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, ok := field.Tag.Lookup("goml")
+		if !ok || tag == "-" || field.PkgPath != "" {
+			continue
+		}
+		name, flags := parseTag(tag)
+		fv := rv.Field(i)
+
+		switch {
+		case hasFlag(flags, "child"):
+			if err := marshalChildren(&e, name, fv); err != nil {
+				return e, err
+			}
+		case hasFlag(flags, "text"):
+			if fv.Kind() != reflect.String {
+				return e, ErrMarshal.Unsupported.Args(field.Name, field.Type)
+			}
+			if fv.String() != "" {
+				text := NDiv()
+				text.Name = "text"
+				text.Attributes["text"] = []string{fv.String()}
+				e.Children = append(e.Children, text)
+			}
+		case field.Type == styleType:
+			if !fv.IsNil() {
+				e.Style = fv.Interface().(goss.Style)
+			}
+		default:
+			if fv.Kind() == reflect.Ptr && fv.IsNil() || hasFlag(flags, "omitempty") && fv.IsZero() {
+				continue
+			}
+			if !supported(field.Type) || !formattable(field.Type) {
+				return e, ErrMarshal.Unsupported.Args(field.Name, field.Type)
+			}
+			values, err := formatValues(fv)
+			if err != nil {
+				return e, ErrMarshal.Value.Args(name, e.Name, field.Type).Wrap(err)
+			}
+			e.Attributes[name] = values
+		}
+	}
+	return e, nil
+}
+
+// marshalChildren encodes child field
+func marshalChildren(e *Element, name string, fv reflect.Value) error {
+	if fv.Kind() != reflect.Slice {
+		if absent(fv) {
+			return nil
+		}
+		ch, err := marshalValue(name, fv)
+		if err != nil {
+			return ErrMarshal.Child.Args(name, e.Name).Wrap(err)
+		}
+		e.Children = append(e.Children, ch)
+		return nil
+	}
+
+	for i := 0; i < fv.Len(); i++ {
+		item := fv.Index(i)
+		if absent(item) {
+			continue
+		}
+		ch, err := marshalValue(name, item)
+		if err != nil {
+			return ErrMarshal.Child.Args(name, e.Name).Wrap(err)
+		}
+		e.Children = append(e.Children, ch)
+	}
+	return nil
+}
+
+// absent returns whether child value should be omitted, that is true for nil
+// pointers and Elements with no name
+func absent(fv reflect.Value) bool {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return true
+		}
+		fv = fv.Elem()
+	}
+	return fv.Type() == elementType && fv.Interface().(Element).Name == ""
+}
+
+// formatValues turns field into attribute values
+func formatValues(fv reflect.Value) ([]string, error) {
+	if fv.Kind() == reflect.Slice && !marshaler(fv.Type()) {
+		values := make([]string, 0, fv.Len())
+		for i := 0; i < fv.Len(); i++ {
+			item := fv.Index(i)
+			if item.Kind() == reflect.Ptr && item.IsNil() {
+				// nil items are omitted same as nil fields
+				continue
+			}
+			v, err := formatValue(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	}
+
+	v, err := formatValue(fv)
+	if err != nil {
+		return nil, err
+	}
+	return []string{v}, nil
+}
+
+// formatValue turns value into string
+func formatValue(fv reflect.Value) (string, error) {
+	if fv.Kind() == reflect.Ptr {
+		fv = fv.Elem()
+	}
+
+	if marshaler(fv.Type()) {
+		if !fv.Type().Implements(textMarshalerType) {
+			// MarshalText has pointer receiver
+			if !fv.CanAddr() {
+				ptr := reflect.New(fv.Type())
+				ptr.Elem().Set(fv)
+				fv = ptr.Elem()
+			}
+			fv = fv.Addr()
+		}
+		b, err := fv.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+
+	if fv.Type() == durationType {
+		return fv.Interface().(time.Duration).String(), nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(fv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(fv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(fv.Float(), 'g', -1, fv.Type().Bits()), nil
+	}
+	return "", ErrMarshal.Unsupported.Args(fv.Type().Name(), fv.Type())
+}
+
+// marshaler returns whether t or pointer to it implements encoding.TextMarshaler
+func marshaler(t reflect.Type) bool {
+	return t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)
+}
+
+// formattable returns whether formatValues can encode value of type t, it
+// complements supported that checks types Unmarshal can decode into
+func formattable(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice && !marshaler(t) {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if marshaler(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
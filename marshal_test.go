@@ -0,0 +1,144 @@
+package goml
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jakubDoka/goml/core"
+	"github.com/jakubDoka/goml/goss"
+)
+
+func TestMarshal(t *testing.T) {
+	three := 3
+	first := testButton{
+		OnClick: "a",
+		Width:   10,
+		Scale:   1.5,
+		Hidden:  true,
+		Delay:   time.Second,
+		Classes: []string{"a", "b"},
+		Sizes:   []uint8{1, 2},
+		Opt:     &three,
+		Label:   "ok",
+	}
+	l := testList{
+		Name:    "l",
+		Style:   goss.Style{"a": {1}},
+		Buttons: []testButton{first},
+	}
+
+	e, err := Marshal("list", l)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var buff bytes.Buffer
+	Print(&buff, e)
+	if buff.String() != `<list name="l" style="a: 1;">
+	<button class=["a" "b"] delay="1s" hidden="true" onclick="a" opt="3" scale="1.5" sizes=["1" "2"] width="10">ok</>
+</>` {
+		t.Error(buff.String())
+	}
+
+	var res testList
+	if err := Unmarshal(e, &res); err != nil {
+		t.Error(err)
+		return
+	}
+	l.First = &first
+	l.Raw = Element{}
+	core.TestEqual(t, res, l)
+
+	root, err := Marshal("button", []*testButton{{Width: 1}, nil})
+	if len(root.Children) != 1 || root.Children[0].Attributes["width"][0] != "1" || err != nil {
+		t.Error(root, err)
+	}
+
+	if _, err := Marshal("a", 10); !ErrMarshal.Target.SameSurface(err) {
+		t.Error(err)
+	}
+
+	one := 1
+	e, err = Marshal("a", struct {
+		Values []*int `goml:"values"`
+	}{[]*int{&one, nil}})
+	if err != nil {
+		t.Error(err)
+	}
+	core.TestEqual(t, e.Attributes["values"], []string{"1"})
+
+	_, err = Marshal("a", struct {
+		Value failingMarshaler `goml:"value"`
+	}{})
+	if !ErrMarshal.Value.SameSurface(err) {
+		t.Error(err)
+	}
+
+	_, err = Marshal("a", struct {
+		Child struct {
+			Value failingMarshaler `goml:"value"`
+		} `goml:"child,child"`
+	}{})
+	if !ErrMarshal.Child.SameSurface(err) {
+		t.Error(err)
+	}
+
+	type texts struct {
+		One  pointerMarshaler   `goml:"one"`
+		Many []pointerMarshaler `goml:"many"`
+	}
+	in := texts{pointerMarshaler{1}, []pointerMarshaler{{2}, {3}}}
+	for _, v := range []interface{}{in, &in} {
+		e, err = Marshal("a", v)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		core.TestEqual(t, e.Attributes, Attribs{"one": {"p1"}, "many": {"p2", "p3"}})
+		var out texts
+		if err := Unmarshal(e, &out); err != nil {
+			t.Error(err)
+		}
+		core.TestEqual(t, out, in)
+	}
+
+	_, err = Marshal("a", struct {
+		Value unmarshalOnly `goml:"value"`
+	}{})
+	if !ErrMarshal.Unsupported.SameSurface(err) {
+		t.Error(err)
+	}
+}
+
+// pointerMarshaler implements text encoding with pointer receivers
+type pointerMarshaler struct {
+	n int
+}
+
+func (p *pointerMarshaler) MarshalText() ([]byte, error) {
+	return []byte("p" + strconv.Itoa(p.n)), nil
+}
+
+func (p *pointerMarshaler) UnmarshalText(text []byte) (err error) {
+	p.n, err = strconv.Atoi(strings.TrimPrefix(string(text), "p"))
+	return
+}
+
+// unmarshalOnly can be decoded but not encoded
+type unmarshalOnly struct{}
+
+func (*unmarshalOnly) UnmarshalText([]byte) error {
+	return nil
+}
+
+// failingMarshaler is string so Marshal accepts it
+type failingMarshaler string
+
+func (failingMarshaler) MarshalText() ([]byte, error) {
+	return nil, errors.New("failed")
+}
@@ -0,0 +1,53 @@
+package goml
+
+import "sync"
+
+// attribPool holds cleared attribute maps of released elements
+var attribPool = sync.Pool{
+	New: func() interface{} {
+		return Attribs{}
+	},
+}
+
+// newElement returns empty element with attributes map from pool
+func newElement() Element {
+	return Element{Attributes: attribPool.Get().(Attribs)}
+}
+
+// Release returns attribute maps of element and all its children to pool so
+// following parsing can reuse them. Element and its children must not be used
+// after release, it is useful when tree is thrown away after it is processed.
+func Release(e Element) {
+	for _, ch := range e.Children {
+		Release(ch)
+	}
+	if e.Attributes == nil {
+		return
+	}
+	for k := range e.Attributes {
+		delete(e.Attributes, k)
+	}
+	attribPool.Put(e.Attributes)
+}
+
+// intern returns string with content of name, same names share memory within
+// one parse, table is cleared by resetNames so it does not grow with every
+// distinct name parser ever sees
+func (p *Parser) intern(name []byte) string {
+	if str, ok := p.names[string(name)]; ok {
+		return str
+	}
+	if p.names == nil {
+		p.names = map[string]string{}
+	}
+	str := string(name)
+	p.names[str] = str
+	return str
+}
+
+// resetNames clears intern table, it keeps the map so it is not allocated again
+func (p *Parser) resetNames() {
+	for k := range p.names {
+		delete(p.names, k)
+	}
+}
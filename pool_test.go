@@ -0,0 +1,60 @@
+package goml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReleaseProjectedChildren(t *testing.T) {
+	p := NParser(nil)
+	p.AddDefinitions("div", "span")
+	if err := p.AddPrefabs([]byte(`<!twice><div>{children}</><div>{children}</><!/>`)); err != nil {
+		t.Error(err)
+		return
+	}
+
+	src := []byte(`<twice><span a="1"><span b="2"/></></>`)
+	root, err := p.Parse(src)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	Release(root)
+
+	seen := map[uintptr]bool{}
+	var collect func(e Element)
+	collect = func(e Element) {
+		if e.Attributes != nil {
+			ptr := reflect.ValueOf(e.Attributes).Pointer()
+			if seen[ptr] {
+				t.Errorf("attributes of %s are shared", e.Name)
+			}
+			seen[ptr] = true
+		}
+		for _, ch := range e.Children {
+			collect(ch)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		root, err := p.Parse(src)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		collect(root)
+	}
+}
+
+func TestInternReset(t *testing.T) {
+	p := NParser(nil)
+	p.AddDefinitions("div")
+	for _, src := range []string{`<div a="1"/>`, `<div b="1"/>`} {
+		if _, err := p.Parse([]byte(src)); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	if _, ok := p.names["a"]; ok || len(p.names) > 2 {
+		t.Error(p.names)
+	}
+}
@@ -0,0 +1,238 @@
+package goml
+
+import (
+	"io"
+	"sort"
+
+	"github.com/jakubDoka/goml/core"
+)
+
+// UsageKind tells where prefab parameter placeholder is written
+type UsageKind int
+
+// UsageKind variants
+const (
+	// WholeValue is placeholder assigned to attribute, a={name}
+	WholeValue UsageKind = iota
+	// ListItem is placeholder in list, a=["b" {name}]
+	ListItem
+	// InString is placeholder inside string, a="b {name}"
+	InString
+	// Text is placeholder in text of element, <div>b {name}</>
+	Text
+	// Slot is placeholder written alone in text, <div>{name}</>, children of
+	// prefab usage with slot="name" are put in its place, see ChildrenSlot
+	Slot
+)
+
+func (k UsageKind) String() string {
+	switch k {
+	case WholeValue:
+		return "whole value"
+	case ListItem:
+		return "list item"
+	case InString:
+		return "inside string"
+	case Text:
+		return "text"
+	case Slot:
+		return "slot"
+	}
+	return "unknown"
+}
+
+// ParamUsage is one placeholder of prefab parameter
+type ParamUsage struct {
+	Kind UsageKind
+	// Element is name of element placeholder belongs to and Attribute is the
+	// attribute it sets, both are "text" for Text usage
+	Element, Attribute string
+	// Index is index of the item in list for ListItem usage
+	Index int
+
+	Default              string
+	HasDefault, Required bool
+}
+
+// PrefabParam describes parameter of prefab, if parameter is used in multiple
+// placeholders, it is required if any of them is required and first default
+// value is used
+type PrefabParam struct {
+	Name       string
+	Required   bool
+	Default    string
+	HasDefault bool
+	// Usages lists placeholders of parameter in order of definition
+	Usages []ParamUsage
+}
+
+// PrefabInfo describes prefab definition
+type PrefabInfo struct {
+	Name string
+	// Params are sorted by name, ChildrenSlot is not a parameter but named slots
+	// are as they can be filled by attribute as well
+	Params []PrefabParam
+	// Slots are names of slots sorted by name, including ChildrenSlot if
+	// prefab has it
+	Slots []string
+	// File is file prefab was defined in, it is empty if prefab was not
+	// parsed with ParseFile or imported
+	File string
+	// Span covers the definition from '<!' to the end of '<!/>'
+	Span core.Span
+}
+
+// Prefabs returns names of all prefabs parser can use sorted by name
+func (p *Parser) Prefabs() []string {
+	names := make([]string, 0, len(p.prefabs))
+	for name := range p.prefabs {
+		names = append(names, name)
+	}
+	if p.registry != nil {
+		for name := range p.registry.prefabs {
+			if _, ok := p.prefabs[name]; !ok {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Prefab returns description of prefab, false is returned if there is no such prefab
+func (p *Parser) Prefab(name string) (PrefabInfo, bool) {
+	def, ok := p.prefab(name)
+	if !ok {
+		return PrefabInfo{}, false
+	}
+
+	info := PrefabInfo{Name: name, Span: def.Span}
+	if file, ok := p.origins[name]; ok {
+		info.File = file
+	} else if p.registry != nil {
+		info.File = p.registry.origins[name]
+	}
+
+	idx := map[string]int{}
+	def.usages(func(e Element, pd prefabData) {
+		if pd.Name == ChildrenSlot {
+			return
+		}
+		i, ok := idx[pd.Name]
+		if !ok {
+			i = len(info.Params)
+			idx[pd.Name] = i
+			info.Params = append(info.Params, PrefabParam{Name: pd.Name})
+		}
+		param := &info.Params[i]
+		param.Required = param.Required || pd.Required
+		if !param.HasDefault {
+			param.Default, param.HasDefault = pd.Default, pd.HasDefault
+		}
+		param.Usages = append(param.Usages, pd.usage(e))
+	})
+	sort.Slice(info.Params, func(i, j int) bool {
+		return info.Params[i].Name < info.Params[j].Name
+	})
+
+	slots := map[string]bool{}
+	def.slots(slots)
+	for name := range slots {
+		info.Slots = append(info.Slots, name)
+	}
+	sort.Strings(info.Slots)
+
+	return info, true
+}
+
+// ExportPrefabs writes definitions of named prefabs, or all prefabs if no names
+// are given, as goml source. Prefabs used by exported prefabs are exported too
+// so the output can be imported into other parser by AddPrefabs or import directive.
+func (p *Parser) ExportPrefabs(w io.Writer, names ...string) error {
+	if len(names) == 0 {
+		names = p.Prefabs()
+	}
+
+	var (
+		defs []Element
+		seen = map[string]bool{}
+		add  func(name string) bool
+	)
+	add = func(name string) bool {
+		if seen[name] {
+			return true
+		}
+		def, ok := p.prefab(name)
+		if !ok {
+			return false
+		}
+		seen[name] = true
+		defs = append(defs, def)
+		def.dependencies(func(name string) {
+			if _, ok := p.prefab(name); ok {
+				add(name)
+			}
+		})
+		return true
+	}
+	for _, name := range names {
+		if !add(name) {
+			return ErrPrefab.Unknown.Args(name)
+		}
+	}
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Name < defs[j].Name
+	})
+
+	var pr Printer
+	for _, def := range defs {
+		if err := pr.PrintPrefab(w, def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// usages calls f for all placeholders in prefab definition in order
+func (d Element) usages(f func(e Element, pd prefabData)) {
+	for _, pd := range d.prefabData {
+		f(d, pd)
+	}
+	for _, ch := range d.Children {
+		ch.usages(f)
+	}
+}
+
+// dependencies calls f with names of all elements used in prefab definition
+func (d Element) dependencies(f func(name string)) {
+	for _, ch := range d.Children {
+		f(ch.Name)
+		ch.dependencies(f)
+	}
+}
+
+// usage describes placeholder that belongs to element e
+func (pd prefabData) usage(e Element) ParamUsage {
+	u := ParamUsage{
+		Element:    e.Name,
+		Attribute:  pd.Target,
+		Default:    pd.Default,
+		HasDefault: pd.HasDefault,
+		Required:   pd.Required,
+	}
+	switch {
+	case pd.Idx == wholeTemplate:
+		u.Kind = WholeValue
+	case pd.Idx == stringTemplate && e.Name == "text" && pd.Target == "text":
+		u.Kind = Text
+		if _, ok := e.slot(); ok {
+			u.Kind = Slot
+		}
+	case pd.Idx == stringTemplate:
+		u.Kind = InString
+	default:
+		u.Kind = ListItem
+		u.Index = pd.Idx
+	}
+	return u
+}
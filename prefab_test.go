@@ -0,0 +1,98 @@
+package goml
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jakubDoka/goml/core"
+)
+
+func TestPrefabList(t *testing.T) {
+	p := NParser(nil)
+	p.AddDefinitions("div")
+	p.SetFS(fstest.MapFS{
+		"lib.goml": {Data: []byte("<!card><div title={title=\"none\"} class=[\"card\" {kind!}]>{title}</><!/>\n<!empty><div/><!/>")},
+	})
+	_, err := p.Parse([]byte(`<@import "lib.goml">
+<!page><card kind={kind="main"} label="a {label}"/><!/>`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	core.TestEqual(t, p.Prefabs(), []string{"card", "empty", "page"})
+
+	info, ok := p.Prefab("card")
+	if !ok {
+		t.Error("card")
+	}
+	core.TestEqual(t, info.Params, []PrefabParam{
+		{
+			Name:     "kind",
+			Required: true,
+			Usages: []ParamUsage{
+				{Kind: ListItem, Element: "div", Attribute: "class", Index: 1, Required: true},
+			},
+		},
+		{
+			Name:       "title",
+			Default:    "none",
+			HasDefault: true,
+			Usages: []ParamUsage{
+				{Kind: WholeValue, Element: "div", Attribute: "title", Default: "none", HasDefault: true},
+				{Kind: Slot, Element: "text", Attribute: "text"},
+			},
+		},
+	})
+	core.TestEqual(t, info.Slots, []string{"title"})
+	if info.File != "lib.goml" || info.Span.Start.Offset != 0 || info.Span.End.Offset != 70 {
+		t.Error(info.File, info.Span)
+	}
+
+	info, _ = p.Prefab("page")
+	if info.File != "" || info.Span.Start.Line != 1 || len(info.Params) != 2 || info.Params[1].Usages[0].Kind != InString {
+		t.Error(info)
+	}
+
+	info, ok = p.Prefab("empty")
+	if !ok || len(info.Params) != 0 || len(info.Slots) != 0 {
+		t.Error(info)
+	}
+
+	if err := p.AddPrefabs([]byte(`<!zed><div a={x}>{children}</><div>b {y}</><!/>`)); err != nil {
+		t.Error(err)
+		return
+	}
+	info, _ = p.Prefab("zed")
+	core.TestEqual(t, info.Slots, []string{ChildrenSlot})
+	if len(info.Params) != 2 || info.Params[0].Name != "x" || info.Params[1].Name != "y" || info.Params[1].Usages[0].Kind != Text {
+		t.Error(info.Params)
+	}
+	if _, ok := p.Prefab("missing"); ok {
+		t.Error("missing")
+	}
+
+	// exported library contains dependencies and can be imported back
+	var buff bytes.Buffer
+	if err := p.ExportPrefabs(&buff, "page"); err != nil {
+		t.Error(err)
+		return
+	}
+	q := NParser(nil)
+	q.AddDefinitions("div")
+	if err := q.AddPrefabs(buff.Bytes()); err != nil {
+		t.Error(err)
+		return
+	}
+	core.TestEqual(t, q.Prefabs(), []string{"card", "page"})
+	for _, name := range q.Prefabs() {
+		a, _ := p.Prefab(name)
+		b, _ := q.Prefab(name)
+		core.TestEqual(t, a.Params, b.Params)
+	}
+
+	if err := p.ExportPrefabs(&buff, "missing"); !ErrPrefab.Unknown.SameSurface(err) {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,297 @@
+package goml
+
+import (
+	"io"
+	"sort"
+	"strings"
+)
+
+// Printer writes Element tree back to goml source
+type Printer struct {
+	// Indent is written for each level of nesting, tab is used if empty
+	Indent string
+	// Prefabs makes printer restore prefab parameters ({name}) from the data parser
+	// stored in elements, this is only useful when printing prefab definitions
+	Prefabs bool
+
+	buff  []byte
+	level int
+}
+
+// Print writes element with default Printer
+func Print(w io.Writer, e Element) error {
+	var pr Printer
+	return pr.Print(w, e)
+}
+
+// Print writes element to w as goml source, if element has no name, it is
+// considered a root and only its children are written. Attributes are sorted
+// by name. Attributes with no values are omitted as goml cannot express them and
+// whitespace at the end of text cannot be preserved.
+func (pr *Printer) Print(w io.Writer, e Element) error {
+	pr.buff = pr.buff[:0]
+	pr.level = 0
+	if e.Name == "" {
+		pr.children(e.Children)
+	} else {
+		pr.element(e)
+	}
+	_, err := w.Write(pr.buff)
+	return err
+}
+
+// PrintPrefab writes prefab definition, def is the element parser stored
+// for the prefab, its name is the prefab name. Parameters are always restored
+// regardless of pr.Prefabs.
+func (pr *Printer) PrintPrefab(w io.Writer, def Element) error {
+	pr.buff = pr.buff[:0]
+	pr.level = 0
+	prefabs := pr.Prefabs
+	pr.Prefabs = true
+
+	pr.buff = append(pr.buff, "<!"...)
+	pr.buff = append(pr.buff, def.Name...)
+	pr.buff = append(pr.buff, ">\n"...)
+	pr.level++
+	pr.children(def.Children)
+	pr.level--
+	pr.buff = append(pr.buff, "<!/>\n"...)
+
+	pr.Prefabs = prefabs
+	_, err := w.Write(pr.buff)
+	return err
+}
+
+// PrintPrefabs writes all prefabs parser currently holds sorted by name
+func (p *Parser) PrintPrefabs(w io.Writer, pr *Printer) error {
+	names := make([]string, 0, len(p.prefabs))
+	for name := range p.prefabs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := pr.PrintPrefab(w, p.prefabs[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// children writes elements on separate lines
+func (pr *Printer) children(elems []Element) {
+	for _, e := range elems {
+		pr.indent()
+		pr.element(e)
+		pr.buff = append(pr.buff, '\n')
+	}
+}
+
+// element writes one element without indentation and trailing newline
+func (pr *Printer) element(e Element) {
+	if e.Name == "text" {
+		pr.text(e)
+		return
+	}
+
+	pr.buff = append(pr.buff, '<')
+	pr.buff = append(pr.buff, e.Name...)
+	pr.attributes(e)
+
+	switch {
+	case len(e.Children) == 0:
+		pr.buff = append(pr.buff, "/>"...)
+	case len(e.Children) == 1 && e.Children[0].Name == "text":
+		pr.buff = append(pr.buff, '>')
+		pr.text(e.Children[0])
+		pr.buff = append(pr.buff, "</>"...)
+	default:
+		pr.buff = append(pr.buff, ">\n"...)
+		pr.level++
+		pr.children(e.Children)
+		pr.level--
+		pr.indent()
+		pr.buff = append(pr.buff, "</>"...)
+	}
+}
+
+// attributes writes attributes of element, each one is prefixed with space
+func (pr *Printer) attributes(e Element) {
+	keys := make([]string, 0, len(e.Attributes)+1)
+	for k := range e.Attributes {
+		keys = append(keys, k)
+	}
+	if _, ok := e.Attributes["style"]; !ok && e.Style != nil {
+		keys = append(keys, "style")
+	}
+	if pr.Prefabs {
+		// whole value templates do not leave anything in attributes
+		for _, pd := range e.prefabData {
+			if _, ok := e.Attributes[pd.Target]; !ok && pd.Idx == wholeTemplate {
+				keys = append(keys, pd.Target)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		values, ok := e.Attributes[k]
+		if !ok && k == "style" {
+			values = []string{e.Style.String()}
+		} else if ok && len(values) == 0 {
+			continue
+		}
+
+		pr.buff = append(pr.buff, ' ')
+		pr.buff = append(pr.buff, k...)
+		pr.buff = append(pr.buff, '=')
+
+		if pd, ok := pr.param(e, k, wholeTemplate); ok {
+			pr.placeholder(pd)
+			continue
+		}
+
+		if _, ok := pr.param(e, k, 0); len(values) == 1 && !ok {
+			pr.value(e, k, values[0])
+			continue
+		}
+
+		pr.buff = append(pr.buff, '[')
+		for i, v := range values {
+			if i != 0 {
+				pr.buff = append(pr.buff, ' ')
+			}
+			if pd, ok := pr.param(e, k, i); ok {
+				pr.placeholder(pd)
+			} else {
+				pr.value(e, k, v)
+			}
+		}
+		pr.buff = append(pr.buff, ']')
+	}
+}
+
+// value writes quoted attribute value
+func (pr *Printer) value(e Element, target, value string) {
+	pr.buff = append(pr.buff, '"')
+	pr.escape(e, target, value, '"')
+	pr.buff = append(pr.buff, '"')
+}
+
+// text writes text element
+func (pr *Printer) text(e Element) {
+	values := e.Attributes["text"]
+	if len(values) == 0 {
+		return
+	}
+	pr.escape(e, "text", values[0], '<')
+}
+
+// escape writes string so parser will decode it back, ending is the byte
+// that terminates the string in source
+func (pr *Printer) escape(e Element, target, str string, ending byte) {
+	text := ending == '<'
+	afterSpace := true
+	for i := 0; i < len(str); i++ {
+		ch := str[i]
+		switch ch {
+		case ' ':
+			if text && afterSpace {
+				pr.buff = append(pr.buff, '\\')
+			}
+		case '{':
+			if pd, ok := pr.stringParam(e, target, str[i:]); ok {
+				pr.placeholder(pd)
+				i += len(pd.Name) + 1
+				afterSpace = false
+				continue
+			}
+			// '{' is escaped by repeating it
+			pr.buff = append(pr.buff, '{')
+		case '\\', ending:
+			pr.buff = append(pr.buff, '\\')
+		case '\a':
+			ch = 'a'
+			pr.buff = append(pr.buff, '\\')
+		case '\b':
+			ch = 'b'
+			pr.buff = append(pr.buff, '\\')
+		case '\f':
+			ch = 'f'
+			pr.buff = append(pr.buff, '\\')
+		case '\n':
+			ch = 'n'
+			pr.buff = append(pr.buff, '\\')
+		case '\r':
+			ch = 'r'
+			pr.buff = append(pr.buff, '\\')
+		case '\t':
+			ch = 't'
+			pr.buff = append(pr.buff, '\\')
+		case '\v':
+			ch = 'v'
+			pr.buff = append(pr.buff, '\\')
+		default:
+			if ch < ' ' || ch == 0x7f {
+				pr.buff = append(pr.buff, '\\', 'x', hexDigits[ch>>4], hexDigits[ch&0xf])
+				afterSpace = false
+				continue
+			}
+		}
+		afterSpace = str[i] == ' '
+		pr.buff = append(pr.buff, ch)
+	}
+}
+
+const hexDigits = "0123456789abcdef"
+
+// stringParam returns parameter whose placeholder is at the start of str if pr.Prefabs
+// is on and element has string template with matching name
+func (pr *Printer) stringParam(e Element, target, str string) (prefabData, bool) {
+	if !pr.Prefabs {
+		return prefabData{}, false
+	}
+	for _, pd := range e.prefabData {
+		if pd.Target == target && pd.Idx == stringTemplate && strings.HasPrefix(str, "{"+pd.Name+"}") {
+			return pd, true
+		}
+	}
+	return prefabData{}, false
+}
+
+// param returns prefab parameter located at given index of target attribute
+func (pr *Printer) param(e Element, target string, idx int) (prefabData, bool) {
+	if !pr.Prefabs {
+		return prefabData{}, false
+	}
+	for _, pd := range e.prefabData {
+		if pd.Target == target && pd.Idx == idx {
+			return pd, true
+		}
+	}
+	return prefabData{}, false
+}
+
+// placeholder writes prefab parameter
+func (pr *Printer) placeholder(pd prefabData) {
+	pr.buff = append(pr.buff, '{')
+	pr.buff = append(pr.buff, pd.Name...)
+	if pd.Required {
+		pr.buff = append(pr.buff, '!')
+	} else if pd.HasDefault {
+		pr.buff = append(pr.buff, '=')
+		pr.value(Element{}, "", pd.Default)
+	}
+	pr.buff = append(pr.buff, '}')
+}
+
+// indent writes indentation for current level
+func (pr *Printer) indent() {
+	indent := pr.Indent
+	if indent == "" {
+		indent = "\t"
+	}
+	for i := 0; i < pr.level; i++ {
+		pr.buff = append(pr.buff, indent...)
+	}
+}
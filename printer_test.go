@@ -0,0 +1,104 @@
+package goml
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/jakubDoka/goml/core"
+	"github.com/jakubDoka/goml/goss"
+)
+
+func TestPrint(t *testing.T) {
+	p := NParser(&goss.Parser{})
+	p.AddDefinitions("div", "button")
+	input := []byte(`
+<div flag list=["a" "b\"c"] style="a: 10; b: 1.5f;">
+	 \ hello\nthere \<{{ \\  man
+	<button onclick="x"/>
+	<button>ok</>
+</>`)
+	d, err := p.Parse(input)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var buff bytes.Buffer
+	if err := Print(&buff, d); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if buff.String() != `<div flag="true" list=["a" "b\"c"] style="a: 10; b: 1.5f;">
+	\ hello\nthere \<{{ \\ man
+	<button onclick="x"/>
+	<button>ok</>
+</>
+` {
+		t.Error(buff.String())
+	}
+
+	d2, err := p.Parse(buff.Bytes())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	core.TestEqual(t, noSpans(d2.Children), noSpans(d.Children))
+
+	buff.Reset()
+	Print(&buff, Element{
+		Name:  "div",
+		Style: goss.Style{"a": {1}},
+	})
+	if buff.String() != `<div style="a: 1;"/>` {
+		t.Error(buff.String())
+	}
+}
+
+func TestPrintPrefabs(t *testing.T) {
+	p := NParser(nil)
+	p.AddDefinitions("div")
+	err := p.AddPrefabs([]byte(`
+<!a>
+	<div h={h} l=[{k}] m=["a" {j!}] s="hello {there="a\"b"} {{x}"/>
+	hello {there}
+<!/>
+<!b><!/>
+	`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var buff bytes.Buffer
+	if err := p.PrintPrefabs(&buff, &Printer{Indent: "  "}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if buff.String() != `<!a>
+  <div h={h} l=[{k}] m=["a" {j!}] s="hello {there="a\"b"} {{x}"/>
+  hello {there}
+<!/>
+<!b>
+<!/>
+` {
+		t.Error(buff.String())
+	}
+
+	p2 := NParser(nil)
+	p2.AddDefinitions("div")
+	if err := p2.AddPrefabs(buff.Bytes()); err != nil {
+		t.Error(err)
+		return
+	}
+
+	for name, pf := range p.prefabs {
+		a, b := noSpans([]Element{pf}), noSpans([]Element{p2.prefabs[name]})
+		if !reflect.DeepEqual(a, b) {
+			t.Errorf("\n%#v\n%#v", a, b)
+		}
+	}
+}
@@ -0,0 +1,446 @@
+package goml
+
+import (
+	"strconv"
+
+	"github.com/jakubDoka/goml/core"
+	"github.com/jakubDoka/sterr"
+)
+
+// ErrSelector stores errors returned by ParseSelector
+var ErrSelector = struct {
+	Empty, Unexpected, Incomplete, NthChild, Pseudo sterr.Err
+}{
+	sterr.New("selector is empty"),
+	sterr.New("unexpected '%c' in selector"),
+	sterr.New("selector is incomplete"),
+	sterr.New("invalid nth-child argument '%s', expected positive integer, odd or even"),
+	sterr.New("unknown pseudo class '%s'"),
+}
+
+// Selector is a compiled selector that can be matched against element trees
+type Selector struct {
+	groups [][]compound
+}
+
+// compound is a part of selector that matches single element, combinator says how it
+// relates to previous compound
+type compound struct {
+	combinator byte // ' ' for descendant, '>' for child, 0 for the first compound
+	name       string
+	attribs    []attribMatcher
+	nth        int // 0 means any, -1 odd, -2 even
+}
+
+// attribMatcher matches attribute by presence or value
+type attribMatcher struct {
+	name, value string
+	hasValue    bool
+}
+
+// ParseSelector compiles selector, supported syntax is subset of css:
+//
+//	button              element with name
+//	*                   any element
+//	#ok                 element with id="ok"
+//	.wide               element with "wide" among class values
+//	[onclick]           element with attribute
+//	[name="ok"]         element with "ok" among attribute values
+//	:nth-child(2)       second child of its parent, also odd and even
+//	div button          button nested anywhere in div
+//	div > button        button that is direct child of div
+//	a, b                element matching a or b
+//
+// Parts can be combined as in 'div.wide > button[onclick]:nth-child(2)'. Text
+// elements do not count as children for :nth-child.
+func ParseSelector(selector string) (Selector, error) {
+	var sp selectorParser
+	sp.Restart([]byte(selector))
+	if !sp.parse() {
+		return Selector{}, sp.Err
+	}
+	return sp.sel, nil
+}
+
+// Find returns the first descendant of e matching selector in document order or nil
+func (e *Element) Find(selector string) (*Element, error) {
+	sel, err := ParseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	return sel.Find(e), nil
+}
+
+// FindAll returns all descendants of e matching selector in document order
+func (e *Element) FindAll(selector string) ([]*Element, error) {
+	sel, err := ParseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	return sel.FindAll(e), nil
+}
+
+// Find returns the first descendant of e matching s in document order or nil
+func (s Selector) Find(e *Element) *Element {
+	var res *Element
+	s.search(e, nil, func(m *Element) bool {
+		res = m
+		return false
+	})
+	return res
+}
+
+// FindAll returns all descendants of e matching s in document order
+func (s Selector) FindAll(e *Element) (res []*Element) {
+	s.search(e, nil, func(m *Element) bool {
+		res = append(res, m)
+		return true
+	})
+	return
+}
+
+// queryNode is element with its position in tree
+type queryNode struct {
+	*Element
+	nth int // 1 based index among non-text siblings
+}
+
+// search visits descendants of e, path holds ancestors of e including e itself,
+// search stops when found returns false
+func (s Selector) search(e *Element, path []queryNode, found func(*Element) bool) bool {
+	if path == nil {
+		path = []queryNode{{e, 1}}
+	}
+	nth := 0
+	for i := range e.Children {
+		ch := &e.Children[i]
+		if ch.Name != "text" {
+			nth++
+		}
+		node := queryNode{ch, nth}
+		if s.match(node, path) && !found(ch) {
+			return false
+		}
+		if !s.search(ch, append(path, node), found) {
+			return false
+		}
+	}
+	return true
+}
+
+// match returns whether node with given ancestors matches any group
+func (s Selector) match(node queryNode, path []queryNode) bool {
+	for _, g := range s.groups {
+		if matchCompounds(g, node, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCompounds matches compounds from right to left, path[0] is the element
+// search started from and cannot be matched itself
+func matchCompounds(cs []compound, node queryNode, path []queryNode) bool {
+	last := cs[len(cs)-1]
+	if !last.matches(node) {
+		return false
+	}
+	if len(cs) == 1 {
+		return true
+	}
+
+	cs = cs[:len(cs)-1]
+	switch last.combinator {
+	case '>':
+		return len(path) > 1 && matchCompounds(cs, path[len(path)-1], path[:len(path)-1])
+	default:
+		for i := len(path) - 1; i > 0; i-- {
+			if matchCompounds(cs, path[i], path[:i]) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// matches returns whether node satisfies compound
+func (c compound) matches(node queryNode) bool {
+	if c.name != "" && c.name != node.Name {
+		return false
+	}
+	switch {
+	case c.nth > 0:
+		if node.Name == "text" || node.nth != c.nth {
+			return false
+		}
+	case c.nth == -1, c.nth == -2:
+		if node.Name == "text" || node.nth%2 != -c.nth%2 {
+			return false
+		}
+	}
+	for _, a := range c.attribs {
+		values, ok := node.Attributes[a.name]
+		if !ok {
+			return false
+		}
+		if !a.hasValue {
+			continue
+		}
+		found := false
+		for _, v := range values {
+			if v == a.value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// selectorParser compiles selector
+type selectorParser struct {
+	core.Parser
+	sel Selector
+}
+
+// parse parses whole selector
+func (sp *selectorParser) parse() bool {
+	var group []compound
+	combinator := byte(0)
+	if !sp.Advance() {
+		sp.Error(ErrSelector.Empty)
+		return false
+	}
+	for {
+		switch sp.Ch {
+		case ' ', '\t', '\n':
+			if combinator == 0 && len(group) != 0 {
+				combinator = ' '
+			}
+			if !sp.Advance() {
+				switch {
+				case len(group) == 0 && len(sp.sel.groups) == 0:
+					sp.Error(ErrSelector.Empty)
+				case len(group) == 0 || combinator == '>':
+					sp.Error(ErrSelector.Incomplete)
+				default:
+					sp.sel.groups = append(sp.sel.groups, group)
+					return true
+				}
+				return false
+			}
+			continue
+		case '>':
+			if len(group) == 0 || combinator == '>' {
+				sp.Error(ErrSelector.Unexpected.Args(sp.Ch))
+				return false
+			}
+			combinator = '>'
+			if sp.AdvanceOr(ErrSelector.Incomplete) {
+				return false
+			}
+			continue
+		case ',':
+			if len(group) == 0 || combinator == '>' {
+				sp.Error(ErrSelector.Unexpected.Args(sp.Ch))
+				return false
+			}
+			sp.sel.groups = append(sp.sel.groups, group)
+			group, combinator = nil, 0
+			if sp.AdvanceOr(ErrSelector.Incomplete) {
+				return false
+			}
+			continue
+		}
+
+		c := compound{combinator: combinator}
+		if len(group) == 0 {
+			c.combinator = 0
+		}
+		more, ok := sp.compound(&c)
+		if !ok {
+			return false
+		}
+		group = append(group, c)
+		combinator = 0
+		if !more {
+			sp.sel.groups = append(sp.sel.groups, group)
+			return true
+		}
+	}
+}
+
+// compound parses one compound, more is false if selector ended
+func (sp *selectorParser) compound(c *compound) (more, ok bool) {
+	empty := true
+	switch sp.Ch {
+	case '*':
+		empty = false
+		if !sp.Advance() {
+			return false, true
+		}
+	default:
+		if name := sp.name(); name != "" {
+			c.name = name
+			empty = false
+			if sp.I >= len(sp.Source) {
+				return false, true
+			}
+		}
+	}
+
+	for {
+		switch sp.Ch {
+		case '#', '.':
+			attrib := "id"
+			if sp.Ch == '.' {
+				attrib = "class"
+			}
+			if sp.AdvanceOr(ErrSelector.Incomplete) {
+				return
+			}
+			value := sp.name()
+			if value == "" {
+				sp.Error(ErrSelector.Unexpected.Args(sp.Ch))
+				return
+			}
+			c.attribs = append(c.attribs, attribMatcher{attrib, value, true})
+		case '[':
+			if !sp.attrib(c) {
+				return
+			}
+		case ':':
+			if !sp.pseudo(c) {
+				return
+			}
+		case ' ', '\t', '\n', '>', ',':
+			if empty {
+				sp.Error(ErrSelector.Unexpected.Args(sp.Ch))
+				return
+			}
+			return true, true
+		default:
+			sp.Error(ErrSelector.Unexpected.Args(sp.Ch))
+			return
+		}
+		empty = false
+		if sp.I >= len(sp.Source) {
+			return false, true
+		}
+	}
+}
+
+// attrib parses '[name]' or '[name="value"]', cursor ends after ']'
+func (sp *selectorParser) attrib(c *compound) bool {
+	if sp.AdvanceOr(ErrSelector.Incomplete) {
+		return false
+	}
+	a := attribMatcher{name: sp.name()}
+	if a.name == "" || sp.I >= len(sp.Source) {
+		sp.Error(ErrSelector.Incomplete)
+		return false
+	}
+	if sp.Ch == '=' {
+		if sp.AdvanceOr(ErrSelector.Incomplete) {
+			return false
+		}
+		a.hasValue = true
+		switch sp.Ch {
+		case '"', '\'':
+			quote := sp.Ch
+			start := sp.I + 1
+			for {
+				if sp.AdvanceOr(ErrSelector.Incomplete) {
+					return false
+				}
+				if sp.Ch == quote {
+					break
+				}
+			}
+			a.value = string(sp.Source[start:sp.I])
+			if sp.AdvanceOr(ErrSelector.Incomplete) {
+				return false
+			}
+		default:
+			a.value = sp.name()
+			if sp.I >= len(sp.Source) {
+				sp.Error(ErrSelector.Incomplete)
+				return false
+			}
+		}
+	}
+	if sp.Ch != ']' {
+		sp.Error(ErrSelector.Unexpected.Args(sp.Ch))
+		return false
+	}
+	c.attribs = append(c.attribs, a)
+	sp.next()
+	return true
+}
+
+// pseudo parses ':nth-child(n)', cursor ends after ')'
+func (sp *selectorParser) pseudo(c *compound) bool {
+	if sp.AdvanceOr(ErrSelector.Incomplete) {
+		return false
+	}
+	name := sp.name()
+	if name != "nth-child" {
+		sp.Error(ErrSelector.Pseudo.Args(name))
+		return false
+	}
+	if sp.I >= len(sp.Source) || sp.Ch != '(' {
+		sp.Error(ErrSelector.Incomplete)
+		return false
+	}
+	if sp.AdvanceOr(ErrSelector.Incomplete) {
+		return false
+	}
+	arg := sp.name()
+	if sp.I >= len(sp.Source) || sp.Ch != ')' {
+		sp.Error(ErrSelector.Incomplete)
+		return false
+	}
+	switch arg {
+	case "odd":
+		c.nth = -1
+	case "even":
+		c.nth = -2
+	default:
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			sp.Error(ErrSelector.NthChild.Args(arg))
+			return false
+		}
+		c.nth = n
+	}
+	sp.next()
+	return true
+}
+
+// name reads identifier that can also contain '-', cursor ends after it,
+// sp.I is len(sp.Source) if selector ended
+func (sp *selectorParser) name() string {
+	start := sp.I
+	for isSelectorIdent(sp.Ch) {
+		if !sp.next() {
+			break
+		}
+	}
+	return string(sp.Source[start:sp.I])
+}
+
+// next advances cursor, when selector ends, sp.I is set to len(sp.Source)
+func (sp *selectorParser) next() bool {
+	if !sp.Advance() {
+		sp.I = len(sp.Source)
+		return false
+	}
+	return true
+}
+
+func isSelectorIdent(ch byte) bool {
+	return ch == '_' || ch == '-' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9'
+}
@@ -0,0 +1,104 @@
+package goml
+
+import (
+	"testing"
+
+	"github.com/jakubDoka/sterr"
+)
+
+func TestFind(t *testing.T) {
+	p := NParser(nil)
+	p.AddDefinitions("div", "button", "span")
+	root, err := p.Parse([]byte(`
+<div id="main" class=["wide" "dark"]>
+	<button name="ok" onclick="a">ok</>
+	text
+	<button name="no">no</>
+	<span>
+		<button name="deep"/>
+	</>
+</>
+<button name="last"/>
+	`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	testCases := []struct {
+		desc, selector string
+		names          []string
+	}{
+		{"name", "button", []string{"ok", "no", "deep", "last"}},
+		{"child", "div > button", []string{"ok", "no"}},
+		{"descendant", "div button", []string{"ok", "no", "deep"}},
+		{"nested child", "#main > span > button", []string{"deep"}},
+		{"presence", "[onclick]", []string{"ok"}},
+		{"value", `[name="no"]`, []string{"no"}},
+		{"unquoted value", `button[name=deep]`, []string{"deep"}},
+		{"nth child", "button:nth-child(2)", []string{"no", "last"}},
+		{"odd", "div > :nth-child(odd)", []string{"ok", "span"}},
+		{"class", ".dark > span *", []string{"deep"}},
+		{"group", "span, [name=last]", []string{"span", "last"}},
+		{"no match", "span > span", nil},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			found, err := root.FindAll(tC.selector)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			var names []string
+			for _, e := range found {
+				if n, ok := e.Attributes["name"]; ok {
+					names = append(names, n[0])
+				} else {
+					names = append(names, e.Name)
+				}
+			}
+			if len(names) != len(tC.names) {
+				t.Error(names)
+				return
+			}
+			for i := range names {
+				if names[i] != tC.names[i] {
+					t.Error(names)
+				}
+			}
+		})
+	}
+
+	e, err := root.Find("span button")
+	if err != nil || e == nil {
+		t.Error(e, err)
+		return
+	}
+	e.Attributes["name"][0] = "changed"
+	if e, _ := root.Find(`[name="changed"]`); e == nil {
+		t.Error("Find has to return pointer into the tree")
+	}
+	if e, _ := root.Find("p"); e != nil {
+		t.Error(e)
+	}
+
+	errs := []struct {
+		selector string
+		err      sterr.Err
+	}{
+		{"", ErrSelector.Empty},
+		{"div >", ErrSelector.Incomplete},
+		{"div > ", ErrSelector.Incomplete},
+		{"> div", ErrSelector.Unexpected},
+		{"div, ", ErrSelector.Incomplete},
+		{"[name", ErrSelector.Incomplete},
+		{"div:first-child", ErrSelector.Pseudo},
+		{":nth-child(0)", ErrSelector.NthChild},
+		{"div!", ErrSelector.Unexpected},
+	}
+	for _, tC := range errs {
+		if _, err := ParseSelector(tC.selector); !tC.err.SameSurface(err) {
+			t.Error(tC.selector, err)
+		}
+	}
+}
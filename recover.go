@@ -0,0 +1,85 @@
+package goml
+
+import "github.com/jakubDoka/goml/core"
+
+// Diagnostic is an error found during recovering parse or validation
+type Diagnostic struct {
+	Pos core.Pos
+	// Err is *ParseError for diagnostics of ParseRecover and Validate
+	Err error
+}
+
+// Error implements error interface
+func (d Diagnostic) Error() string {
+	return d.Err.Error()
+}
+
+// diagnose moves p.Err into diagnostics
+func (p *Parser) diagnose() {
+	p.diagnostics = append(p.diagnostics, Diagnostic{p.Pos(), p.newParseError()})
+	p.Err = nil
+}
+
+// recover records current error and skips source until the end of malformed
+// construct that started at begin so parsing can continue, if element definition
+// was malformed, element is still added with attributes that were parsed successfully
+// so the tree structure is preserved
+func (p *Parser) recover(begin int) {
+	p.diagnose()
+	open := p.open
+	p.open = false
+
+	for {
+		switch p.Ch {
+		case '<':
+			if p.I == 0 || p.Source[p.I-1] != '\\' {
+				// '<' that started the construct is skipped, otherwise
+				// it would be parsed again forever
+				if p.I > begin {
+					p.Degrade()
+				}
+				return
+			}
+		case '>':
+			if open {
+				p.recoverElement()
+			}
+			return
+		case '\n':
+			p.NewLine()
+		}
+		if !p.Advance() {
+			return
+		}
+	}
+}
+
+// recoverElement adds malformed element, p.Ch has to be '>' that terminates it
+func (p *Parser) recoverElement() {
+	p.parsed.Span.End = p.End()
+	i := p.I - 1
+	for i >= 0 && p.Source[i] == ' ' {
+		i--
+	}
+
+	if i < 0 || p.Source[i] != '/' {
+		p.stack.Push(p.parsed)
+		return
+	}
+
+	// prefab cannot be expanded with incomplete attributes
+	if _, ok := p.prefab(p.parsed.Name); !ok {
+		p.add(p.parsed)
+	}
+}
+
+// closeAll closes all elements that remained on stack
+func (p *Parser) closeAll() {
+	end := p.SourceEnd()
+	for p.stack.CanPop() {
+		d := p.stack.Pop()
+		d.Span.End = end
+		p.add(d)
+	}
+	p.inPrefab = false
+}
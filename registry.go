@@ -0,0 +1,121 @@
+package goml
+
+import (
+	"io/fs"
+	"path"
+	"sync"
+
+	"github.com/jakubDoka/goml/goss"
+)
+
+// Registry is frozen set of definitions, schemas and prefabs that can be used
+// to parse from many goroutines at once, each call uses its own parser state
+type Registry struct {
+	defined  map[string]bool
+	schemas  map[string]Schema
+	prefabs  map[string]Element
+	imported map[string]bool
+	origins  map[string]string
+	fsys     fs.FS
+	styles   bool
+
+	pool sync.Pool
+}
+
+// Freeze returns registry with copy of definitions, schemas and prefabs parser
+// currently holds, later changes to parser do not affect the registry. If parser
+// has goss parser, registry parses styles with its own goss parsers.
+func (p *Parser) Freeze() *Registry {
+	r := &Registry{
+		defined:  make(map[string]bool, len(p.defined)),
+		schemas:  make(map[string]Schema, len(p.schemas)),
+		prefabs:  make(map[string]Element, len(p.prefabs)),
+		imported: make(map[string]bool, len(p.imported)),
+		origins:  make(map[string]string, len(p.origins)),
+		fsys:     p.fsys,
+		styles:   p.gs != nil,
+	}
+	for k, v := range p.imported {
+		r.imported[k] = v
+	}
+	for k, v := range p.origins {
+		r.origins[k] = v
+	}
+	for k, v := range p.defined {
+		r.defined[k] = v
+	}
+	for k, v := range p.schemas {
+		r.schemas[k] = v
+	}
+	for k, v := range p.prefabs {
+		r.prefabs[k] = v
+	}
+	return r
+}
+
+// Parse is goroutine safe equivalent of Parser.Parse, prefabs defined in source
+// are visible only within the call
+func (r *Registry) Parse(Source []byte) (Element, error) {
+	p := r.get()
+	defer r.pool.Put(p)
+	return p.Parse(Source)
+}
+
+// ParseFile is goroutine safe equivalent of Parser.ParseFile, files imported
+// before freezing are not imported again
+func (r *Registry) ParseFile(name string) (Element, error) {
+	p := r.get()
+	defer r.pool.Put(p)
+	return p.ParseFile(name)
+}
+
+// Imported returns whether file was parsed with ParseFile or imported by parser
+// before freezing, such file is not imported again
+func (r *Registry) Imported(name string) bool {
+	return r.imported[path.Clean(name)]
+}
+
+// ParseRecover is goroutine safe equivalent of Parser.ParseRecover
+func (r *Registry) ParseRecover(Source []byte) (Element, []Diagnostic) {
+	p := r.get()
+	defer r.pool.Put(p)
+	return p.ParseRecover(Source)
+}
+
+// Validate is goroutine safe equivalent of Parser.Validate
+func (r *Registry) Validate(e Element) []Diagnostic {
+	p := r.get()
+	defer r.pool.Put(p)
+	return p.Validate(e)
+}
+
+// get returns parser with clean state that reads definitions from registry
+func (r *Registry) get() *Parser {
+	if p, ok := r.pool.Get().(*Parser); ok {
+		p.ClearPrefabs()
+		return p
+	}
+	p := &Parser{
+		defined:  r.defined,
+		schemas:  r.schemas,
+		prefabs:  map[string]Element{},
+		registry: r,
+		fsys:     r.fsys,
+	}
+	if r.styles {
+		p.gs = &goss.Parser{}
+	}
+	return p
+}
+
+// prefab looks prefab up in parser and then in registry if parser has one
+func (p *Parser) prefab(name string) (Element, bool) {
+	if pf, ok := p.prefabs[name]; ok {
+		return pf, true
+	}
+	if p.registry != nil {
+		pf, ok := p.registry.prefabs[name]
+		return pf, ok
+	}
+	return Element{}, false
+}
@@ -0,0 +1,69 @@
+package goml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/jakubDoka/goml/core"
+	"github.com/jakubDoka/goml/goss"
+)
+
+func TestRegistry(t *testing.T) {
+	p := NParser(&goss.Parser{})
+	p.AddDefinitions("div", "span")
+	if err := p.AddPrefabs([]byte(`<!item><div n={n} style="a: 1;">{children}</><!/>`)); err != nil {
+		t.Error(err)
+		return
+	}
+	r := p.Freeze()
+
+	// registry does not see later changes
+	p.RemoveDefinitions("span")
+	p.ClearPrefabs()
+
+	expected := func(i int) Element {
+		e, err := p.Parse([]byte(fmt.Sprintf(`<div n="%d" style="a: 1;"><span/>%d</>`, i, i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+
+	var wg sync.WaitGroup
+	results := make([]Element, 50)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = r.Parse([]byte(fmt.Sprintf(`<!local><span/><!/><item n="%d"><local/>%d</>`, i, i)))
+		}(i)
+	}
+	wg.Wait()
+
+	p.AddDefinitions("span")
+	for i := range results {
+		if errs[i] != nil {
+			t.Error(errs[i])
+			continue
+		}
+		var a, b bytes.Buffer
+		Print(&a, results[i])
+		Print(&b, expected(i))
+		if a.String() != b.String() {
+			t.Error(a.String(), b.String())
+		}
+		core.TestEqual(t, results[i].Children[0].Style, goss.Style{"a": {1}})
+	}
+
+	// local prefabs do not leak between calls
+	if _, err := r.Parse([]byte(`<local/>`)); !errors.Is(err, ErrUnknown) {
+		t.Error(err)
+	}
+	if _, err := r.Parse([]byte(`<!item><div/><!/>`)); !errors.Is(err, ErrPrefab.Shadow) {
+		t.Error(err)
+	}
+}
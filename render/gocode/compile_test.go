@@ -0,0 +1,110 @@
+package gocode
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/jakubDoka/goml"
+	"github.com/jakubDoka/goml/goss"
+)
+
+// program prints trees returned by generated functions
+const program = `package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/jakubDoka/goml"
+)
+
+func main() {
+	data, err := json.Marshal(Plain())
+	if err != nil {
+		panic(err)
+	}
+	os.Stdout.Write(append(data, '\n'))
+	goml.Print(os.Stdout, Prefabs())
+}
+`
+
+func TestGenerateCompiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go command")
+	}
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	p := goml.NParser(&goss.Parser{})
+	p.AddDefinitions("div", "span")
+	plain, err := p.Parse([]byte(`
+<div class=["a" "b"] style="w: 10 1.5; h: {{a: b;};">
+	text with "quotes" and \<
+	<span id="a" size=["1" "2"]/>
+</>`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	prefabs, err := p.Parse([]byte(`
+<!card><div class=["card" {kind="plain"}]><span>{title!}</>{children}</><!/>
+<card title="a"><span/></>`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	g := Generator{Package: "main", Spans: true}
+	src, err := g.Generate(Func{Name: "Plain", Root: plain}, Func{Name: "Prefabs", Root: prefabs})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// directory is inside module so generated code imports goml from this tree,
+	// leading underscore hides it from ./... patterns
+	dir, err := ioutil.TempDir(".", "_gentest")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "layouts.go"), src, 0644); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(program), 0644); err != nil {
+		t.Error(err)
+		return
+	}
+
+	cmd := exec.Command(gobin, "run", "./"+filepath.Base(dir))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Error(err, stderr.String())
+		return
+	}
+
+	// generated tree with spans has to be identical to parsed one, prefab
+	// parameter data is not generated so prefab tree is compared printed
+	data, err := json.Marshal(plain)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var expected bytes.Buffer
+	expected.Write(append(data, '\n'))
+	goml.Print(&expected, prefabs)
+	if string(out) != expected.String() {
+		t.Errorf("%s\n%s", out, expected.String())
+	}
+}
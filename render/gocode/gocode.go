@@ -0,0 +1,274 @@
+// Package gocode renders goml element trees as Go source that constructs them
+// with composite literals, so layouts can be compiled into binary instead of
+// being parsed at runtime
+package gocode
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jakubDoka/goml"
+	"github.com/jakubDoka/goml/core"
+	"github.com/jakubDoka/goml/goss"
+	"github.com/jakubDoka/sterr"
+)
+
+// ErrGenerate contains errors returned by Generator
+var ErrGenerate = struct {
+	Name, Duplicate, Value sterr.Err
+}{
+	sterr.New("'%s' is not valid Go identifier"),
+	sterr.New("function '%s' is generated twice"),
+	sterr.New("style value of type %T cannot be generated"),
+}
+
+// Func is a generated function that returns Root
+type Func struct {
+	// Name has to be valid Go identifier, see FuncName
+	Name string
+	// Doc is written as function comment if not empty
+	Doc  string
+	Root goml.Element
+}
+
+// Generator writes Go file with functions returning element trees. Each call of
+// generated function builds new tree so caller can modify it. Elements created
+// from prefabs lose parameter data that Printer uses to restore prefab definitions,
+// as it is unexported, otherwise trees are identical to what parser produced.
+type Generator struct {
+	// Package is name of package in generated file
+	Package string
+	// Spans makes generator include source spans of elements, they are omitted
+	// by default to keep generated code small
+	Spans bool
+	// Source is mentioned in the header comment of generated file
+	Source string
+
+	buff        []byte
+	goss, spans bool
+}
+
+// Generate returns gofmt-ed source of Go file with given functions
+func (g *Generator) Generate(funcs ...Func) ([]byte, error) {
+	seen := map[string]bool{}
+	for _, f := range funcs {
+		if !token.IsIdentifier(f.Name) {
+			return nil, ErrGenerate.Name.Args(f.Name)
+		}
+		if seen[f.Name] {
+			return nil, ErrGenerate.Duplicate.Args(f.Name)
+		}
+		seen[f.Name] = true
+	}
+
+	// body is generated first so imports are known
+	g.buff, g.goss, g.spans = g.buff[:0], false, false
+	for _, f := range funcs {
+		g.buff = append(g.buff, '\n')
+		if f.Doc != "" {
+			for _, line := range strings.Split(f.Doc, "\n") {
+				g.buff = append(g.buff, "// "...)
+				g.buff = append(g.buff, line...)
+				g.buff = append(g.buff, '\n')
+			}
+		}
+		g.buff = append(g.buff, "func "...)
+		g.buff = append(g.buff, f.Name...)
+		g.buff = append(g.buff, "() goml.Element {\nreturn "...)
+		if err := g.element(f.Root, true); err != nil {
+			return nil, err
+		}
+		g.buff = append(g.buff, "\n}\n"...)
+	}
+	body := g.buff
+
+	header := "// Code generated by goml gen"
+	if g.Source != "" {
+		header += " from " + g.Source
+	}
+	header += ". DO NOT EDIT.\n\npackage " + g.Package + "\n\nimport (\n\t\"github.com/jakubDoka/goml\"\n"
+	if g.spans {
+		header += "\t\"github.com/jakubDoka/goml/core\"\n"
+	}
+	if g.goss {
+		header += "\t\"github.com/jakubDoka/goml/goss\"\n"
+	}
+	header += ")\n"
+
+	g.buff = append([]byte(header), body...)
+	return format.Source(g.buff)
+}
+
+// element writes element literal, type is omitted if element is in slice
+func (g *Generator) element(e goml.Element, typed bool) error {
+	if typed {
+		g.buff = append(g.buff, "goml.Element"...)
+	}
+	g.buff = append(g.buff, "{\n"...)
+	if e.Name != "" {
+		g.buff = append(g.buff, "Name: "...)
+		g.buff = strconv.AppendQuote(g.buff, e.Name)
+		g.buff = append(g.buff, ",\n"...)
+	}
+	if e.Attributes != nil {
+		g.attributes(e.Attributes)
+	}
+	if e.Style != nil {
+		g.buff = append(g.buff, "Style: "...)
+		if err := g.style(e.Style); err != nil {
+			return err
+		}
+		g.buff = append(g.buff, ",\n"...)
+	}
+	if e.Children != nil {
+		g.buff = append(g.buff, "Children: []goml.Element{\n"...)
+		for _, ch := range e.Children {
+			if err := g.element(ch, false); err != nil {
+				return err
+			}
+			g.buff = append(g.buff, ",\n"...)
+		}
+		g.buff = append(g.buff, "},\n"...)
+	}
+	if g.Spans && e.Span != (core.Span{}) {
+		g.spans = true
+		g.buff = append(g.buff, "Span: core.Span{Start: "...)
+		g.pos(e.Span.Start)
+		g.buff = append(g.buff, ", End: "...)
+		g.pos(e.Span.End)
+		g.buff = append(g.buff, "},\n"...)
+	}
+	g.buff = append(g.buff, '}')
+	return nil
+}
+
+// attributes writes attributes sorted by name
+func (g *Generator) attributes(a goml.Attribs) {
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	g.buff = append(g.buff, "Attributes: goml.Attribs{"...)
+	for _, k := range keys {
+		g.buff = append(g.buff, '\n')
+		g.buff = strconv.AppendQuote(g.buff, k)
+		if a[k] == nil {
+			g.buff = append(g.buff, ": nil,"...)
+			continue
+		}
+		g.buff = append(g.buff, ": {"...)
+		for i, v := range a[k] {
+			if i != 0 {
+				g.buff = append(g.buff, ", "...)
+			}
+			g.buff = strconv.AppendQuote(g.buff, v)
+		}
+		g.buff = append(g.buff, "},"...)
+	}
+	if len(keys) != 0 {
+		g.buff = append(g.buff, '\n')
+	}
+	g.buff = append(g.buff, "},\n"...)
+}
+
+// style writes style literal with properties sorted by name
+func (g *Generator) style(s goss.Style) error {
+	g.goss = true
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	g.buff = append(g.buff, "goss.Style{"...)
+	for _, k := range keys {
+		g.buff = append(g.buff, '\n')
+		g.buff = strconv.AppendQuote(g.buff, k)
+		if s[k] == nil {
+			g.buff = append(g.buff, ": nil,"...)
+			continue
+		}
+		g.buff = append(g.buff, ": {"...)
+		for i, v := range s[k] {
+			if i != 0 {
+				g.buff = append(g.buff, ", "...)
+			}
+			if err := g.value(v); err != nil {
+				return err
+			}
+		}
+		g.buff = append(g.buff, "},"...)
+	}
+	if len(keys) != 0 {
+		g.buff = append(g.buff, '\n')
+	}
+	g.buff = append(g.buff, '}')
+	return nil
+}
+
+// value writes style value, types that differ from default type of untyped
+// constant are converted explicitly
+func (g *Generator) value(v interface{}) error {
+	switch v := v.(type) {
+	case goss.Style:
+		return g.style(v)
+	case int:
+		g.buff = strconv.AppendInt(g.buff, int64(v), 10)
+	case uint64:
+		g.buff = append(g.buff, "uint64("...)
+		g.buff = strconv.AppendUint(g.buff, v, 10)
+		g.buff = append(g.buff, ')')
+	case float64:
+		g.buff = append(g.buff, "float64("...)
+		g.buff = strconv.AppendFloat(g.buff, v, 'g', -1, 64)
+		g.buff = append(g.buff, ')')
+	case string:
+		g.buff = strconv.AppendQuote(g.buff, v)
+	default:
+		return ErrGenerate.Value.Args(v)
+	}
+	return nil
+}
+
+func (g *Generator) pos(p core.Pos) {
+	g.buff = append(g.buff, fmt.Sprintf("core.Pos{Offset: %d, Line: %d, Column: %d}", p.Offset, p.Line, p.Column)...)
+}
+
+// FuncName derives exported function name from file name, extension and
+// directories are dropped and words separated by non-alphanumeric characters
+// are joined in camel case, for example "ui/user-card.goml" becomes "UserCard"
+func FuncName(file string) string {
+	if i := strings.LastIndexAny(file, `/\`); i != -1 {
+		file = file[i+1:]
+	}
+	if i := strings.LastIndexByte(file, '.'); i > 0 {
+		file = file[:i]
+	}
+
+	var sb strings.Builder
+	upper := true
+	for _, r := range file {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+
+	name := sb.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "Goml" + name
+	}
+	return name
+}
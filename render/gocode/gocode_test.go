@@ -0,0 +1,139 @@
+package gocode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jakubDoka/goml"
+	"github.com/jakubDoka/goml/goss"
+	"github.com/jakubDoka/sterr"
+)
+
+func TestGenerate(t *testing.T) {
+	p := goml.NParser(&goss.Parser{})
+	p.AddDefinitions("div")
+	root, err := p.Parse([]byte(`
+<!card><div class=["card" {kind="plain"}]>{title!}</><!/>
+<card title="a\"b"/>
+<div style="w: 10 1.5; h: {{a: b;};"/>`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	g := Generator{Package: "ui", Source: "main.goml"}
+	out, err := g.Generate(Func{Name: "Main", Doc: "Main is the main layout", Root: root})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := `// Code generated by goml gen from main.goml. DO NOT EDIT.
+
+package ui
+
+import (
+	"github.com/jakubDoka/goml"
+	"github.com/jakubDoka/goml/goss"
+)
+
+// Main is the main layout
+func Main() goml.Element {
+	return goml.Element{
+		Attributes: goml.Attribs{},
+		Children: []goml.Element{
+			{
+				Name: "div",
+				Attributes: goml.Attribs{
+					"class": {"card", "plain"},
+				},
+				Children: []goml.Element{
+					{
+						Name: "text",
+						Attributes: goml.Attribs{
+							"text": {"a\"b"},
+						},
+						Children: []goml.Element{},
+					},
+				},
+			},
+			{
+				Name: "div",
+				Attributes: goml.Attribs{
+					"style": {"w: 10 1.5; h: {a: b;};"},
+				},
+				Style: goss.Style{
+					"h": {goss.Style{
+						"a": {"b"},
+					}},
+					"w": {10, float64(1.5)},
+				},
+			},
+		},
+	}
+}
+`
+	if string(out) != expected {
+		t.Error(string(out))
+	}
+
+	testCases := []struct {
+		desc  string
+		funcs []Func
+		err   sterr.Err
+	}{
+		{
+			desc:  "name",
+			funcs: []Func{{Name: "a-b"}},
+			err:   ErrGenerate.Name,
+		},
+		{
+			desc:  "duplicate",
+			funcs: []Func{{Name: "A"}, {Name: "A"}},
+			err:   ErrGenerate.Duplicate,
+		},
+		{
+			desc:  "value",
+			funcs: []Func{{Name: "A", Root: goml.Element{Style: goss.Style{"a": {true}}}}},
+			err:   ErrGenerate.Value,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			_, err := g.Generate(tC.funcs...)
+			if !tC.err.SameSurface(err) {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestGenerateUint(t *testing.T) {
+	g := Generator{Package: "ui"}
+	out, err := g.Generate(Func{Name: "A", Root: goml.Element{Style: goss.Style{"a": {uint64(1) << 63}}}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !strings.Contains(string(out), `"a": {uint64(9223372036854775808)},`) {
+		t.Error(string(out))
+	}
+}
+
+func TestFuncName(t *testing.T) {
+	testCases := []struct {
+		input, output string
+	}{
+		{"main.goml", "Main"},
+		{"ui/user-card.goml", "UserCard"},
+		{`ui\side_bar.v2.goml`, "SideBarV2"},
+		{"404.goml", "Goml404"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.input, func(t *testing.T) {
+			if out := FuncName(tC.input); out != tC.output {
+				t.Error(out)
+			}
+		})
+	}
+}
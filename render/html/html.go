@@ -0,0 +1,238 @@
+// Package html renders goml element trees as html5
+package html
+
+import (
+	stdhtml "html"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jakubDoka/goml"
+	"github.com/jakubDoka/goml/goss"
+	"github.com/jakubDoka/sterr"
+)
+
+// ErrName is returned by Render when element is rendered with tag or has
+// attribute whose name cannot be written into html
+var ErrName = sterr.New("'%s' is not valid html %s name")
+
+// voidElements cannot have children nor closing tag
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"source": true, "track": true, "wbr": true,
+}
+
+// Renderer writes goml elements as html
+type Renderer struct {
+	// Tag maps element to html tag, if it returns empty string, only children of element
+	// are rendered. If Tag is nil, element name is used.
+	Tag func(e goml.Element) string
+
+	buff []byte
+}
+
+// Render writes element with default Renderer
+func Render(w io.Writer, e goml.Element) error {
+	var r Renderer
+	return r.Render(w, e)
+}
+
+// Render writes element to w as html, if element has no name it is considered a root
+// and only its children are written. Text elements become escaped text nodes, list
+// attributes are joined by space and attributes are sorted by name. Style attribute
+// is replaced by inline css built from Element.Style, see CSS. Tags and attribute
+// names are not escaped, ErrName is returned if they are not valid html names and
+// nothing is written.
+func (r *Renderer) Render(w io.Writer, e goml.Element) error {
+	r.buff = r.buff[:0]
+	var err error
+	if e.Name == "" {
+		err = r.children(e.Children)
+	} else {
+		err = r.element(e)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(r.buff)
+	return err
+}
+
+// children writes elements without separators, whitespace is significant in html
+func (r *Renderer) children(elems []goml.Element) error {
+	for _, e := range elems {
+		if err := r.element(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// element writes one element with its children
+func (r *Renderer) element(e goml.Element) error {
+	if e.Name == "text" {
+		if values := e.Attributes["text"]; len(values) != 0 {
+			r.buff = append(r.buff, stdhtml.EscapeString(values[0])...)
+		}
+		return nil
+	}
+
+	tag := e.Name
+	if r.Tag != nil {
+		tag = r.Tag(e)
+	}
+	if tag == "" {
+		return r.children(e.Children)
+	}
+	if !validTag(tag) {
+		return ErrName.Args(tag, "tag")
+	}
+
+	r.buff = append(r.buff, '<')
+	r.buff = append(r.buff, tag...)
+	if err := r.attributes(e); err != nil {
+		return err
+	}
+	r.buff = append(r.buff, '>')
+
+	if voidElements[tag] {
+		return nil
+	}
+
+	if err := r.children(e.Children); err != nil {
+		return err
+	}
+	r.buff = append(r.buff, "</"...)
+	r.buff = append(r.buff, tag...)
+	r.buff = append(r.buff, '>')
+	return nil
+}
+
+// attributes writes attributes of element, each one is prefixed with space
+func (r *Renderer) attributes(e goml.Element) error {
+	keys := make([]string, 0, len(e.Attributes)+1)
+	for k := range e.Attributes {
+		if k != "style" {
+			keys = append(keys, k)
+		}
+	}
+	css := CSS(e.Style)
+	if e.Style == nil {
+		// parser without goss keeps style as plain attribute
+		css = strings.Join(e.Attributes["style"], " ")
+	}
+	if css != "" {
+		keys = append(keys, "style")
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if !validAttribute(k) {
+			return ErrName.Args(k, "attribute")
+		}
+		value := css
+		if k != "style" {
+			value = strings.Join(e.Attributes[k], " ")
+		}
+		r.buff = append(r.buff, ' ')
+		r.buff = append(r.buff, k...)
+		r.buff = append(r.buff, `="`...)
+		r.buff = append(r.buff, stdhtml.EscapeString(value)...)
+		r.buff = append(r.buff, '"')
+	}
+	return nil
+}
+
+// validTag returns whether tag starts with ascii letter and contains only ascii
+// letters, digits, '-', '_', '.' and ':', which covers custom elements
+func validTag(tag string) bool {
+	for i := 0; i < len(tag); i++ {
+		c := tag[i]
+		letter := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+		if i == 0 && !letter {
+			return false
+		}
+		if !letter && !(c >= '0' && c <= '9') && c != '-' && c != '_' && c != '.' && c != ':' {
+			return false
+		}
+	}
+	return tag != ""
+}
+
+// validAttribute returns whether name is non-empty and has no whitespace, control
+// characters, quotes, '>', '/', '=' or '<' as html requires
+func validAttribute(name string) bool {
+	for _, c := range name {
+		if c <= ' ' || c == 0x7f || strings.ContainsRune(`"'>/=<`, c) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// unitless lists css properties whose numbers are not lengths
+var unitless = map[string]bool{
+	"opacity": true, "z-index": true, "line-height": true, "font-weight": true,
+	"flex": true, "flex-grow": true, "flex-shrink": true, "order": true,
+	"zoom": true, "orphans": true, "widows": true, "tab-size": true,
+	"column-count": true, "fill-opacity": true, "stroke-opacity": true,
+}
+
+// CSS turns style into inline css declarations sorted by property. Property names
+// and keywords have '_' replaced with '-', non-zero numbers get 'px' unit unless
+// property takes plain numbers, like opacity or line-height, and nested styles are
+// skipped as inline css cannot express them.
+func CSS(s goss.Style) string {
+	keys := make([]string, 0, len(s))
+	for k, values := range s {
+		if len(values) != 0 {
+			if _, ok := values[0].(goss.Style); !ok {
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	var buff []byte
+	for _, k := range keys {
+		if len(buff) != 0 {
+			buff = append(buff, ' ')
+		}
+		name := strings.ReplaceAll(k, "_", "-")
+		unit := "px"
+		if unitless[name] {
+			unit = ""
+		}
+		buff = append(buff, name...)
+		buff = append(buff, ':')
+		for _, v := range s[k] {
+			switch v := v.(type) {
+			case int:
+				buff = append(buff, ' ')
+				buff = strconv.AppendInt(buff, int64(v), 10)
+				if v != 0 {
+					buff = append(buff, unit...)
+				}
+			case uint64:
+				buff = append(buff, ' ')
+				buff = strconv.AppendUint(buff, v, 10)
+				if v != 0 {
+					buff = append(buff, unit...)
+				}
+			case float64:
+				buff = append(buff, ' ')
+				buff = strconv.AppendFloat(buff, v, 'f', -1, 64)
+				if v != 0 {
+					buff = append(buff, unit...)
+				}
+			case string:
+				buff = append(buff, ' ')
+				buff = append(buff, strings.ReplaceAll(v, "_", "-")...)
+			}
+		}
+		buff = append(buff, ';')
+	}
+	return string(buff)
+}
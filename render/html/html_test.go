@@ -0,0 +1,110 @@
+package html
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jakubDoka/goml"
+	"github.com/jakubDoka/goml/goss"
+)
+
+func TestRender(t *testing.T) {
+	p := goml.NParser(&goss.Parser{})
+	p.AddDefinitions("div", "img", "button", "group")
+	root, err := p.Parse([]byte(`
+<div class=["a" "b"] style="margin_top: 10 1.5; color: red; hover: {{a: b;};">
+	a &lt; "b"
+	<img src="a.png"/>
+	<group><button onclick="x&y">ok</></>
+	<div/>
+</>
+	`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var buff bytes.Buffer
+	r := Renderer{
+		Tag: func(e goml.Element) string {
+			if e.Name == "group" {
+				return ""
+			}
+			return e.Name
+		},
+	}
+	if err := r.Render(&buff, root); err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := `<div class="a b" style="color: red; margin-top: 10px 1.5px;">a &amp;lt; &#34;b&#34;<img src="a.png"><button onclick="x&amp;y">ok</button><div></div></div>`
+	if buff.String() != expected {
+		t.Error(buff.String())
+	}
+}
+
+func TestRenderPlainStyle(t *testing.T) {
+	p := goml.NParser(nil)
+	p.AddDefinitions("div")
+	root, err := p.Parse([]byte(`<div style="color: red;" id="a"/><div style=""/>`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var buff bytes.Buffer
+	if err := (&Renderer{}).Render(&buff, root); err != nil {
+		t.Error(err)
+		return
+	}
+	if buff.String() != `<div id="a" style="color: red;"></div><div></div>` {
+		t.Error(buff.String())
+	}
+}
+
+func TestCSS(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		input  goss.Style
+		output string
+	}{
+		{"lengths", goss.Style{"margin": {0, 4, float64(1.5)}}, "margin: 0 4px 1.5px;"},
+		{"unitless", goss.Style{"line_height": {float64(1.5)}, "z_index": {2}}, "line-height: 1.5; z-index: 2;"},
+		{"keywords", goss.Style{"display": {"inline_block"}}, "display: inline-block;"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if out := CSS(tC.input); out != tC.output {
+				t.Error(out)
+			}
+		})
+	}
+}
+
+func TestRenderInvalidNames(t *testing.T) {
+	testCases := []struct {
+		desc string
+		e    goml.Element
+		tag  string
+	}{
+		{"attribute with quote", goml.Element{Name: "div", Attributes: goml.Attribs{`onclick="x"`: {"y"}}}, ""},
+		{"attribute with space", goml.Element{Name: "div", Attributes: goml.Attribs{"a b": {"y"}}}, ""},
+		{"empty attribute", goml.Element{Name: "div", Attributes: goml.Attribs{"": {"y"}}}, ""},
+		{"tag", goml.Element{Name: "div"}, "div onload=x"},
+		{"tag digit", goml.Element{Name: "div"}, "1div"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			r := Renderer{}
+			if tC.tag != "" {
+				r.Tag = func(goml.Element) string { return tC.tag }
+			}
+			var buff bytes.Buffer
+			err := r.Render(&buff, goml.Element{Children: []goml.Element{tC.e}})
+			if !ErrName.SameSurface(err) || buff.Len() != 0 {
+				t.Error(err, buff.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,456 @@
+package html
+
+import (
+	stdhtml "html"
+	"strconv"
+	"strings"
+
+	"github.com/jakubDoka/goml"
+	"github.com/jakubDoka/goml/core"
+	"github.com/jakubDoka/goml/goss"
+	"github.com/jakubDoka/sterr"
+)
+
+// ErrImport stores diagnostics reported by Import
+var ErrImport = struct {
+	Comment, Declaration, Name, Unclosed, Unexpected, Unterminated, Raw, CSS, Value, Unit, Reserved, Unnamed sterr.Err
+}{
+	sterr.New("comments are not preserved"),
+	sterr.New("doctype and other declarations are ignored"),
+	sterr.New("'%s' is not a valid goml identifier, it is renamed to '%s'"),
+	sterr.New("element '%s' is not closed"),
+	sterr.New("closing tag '%s' does not match any open element"),
+	sterr.New("%s is not terminated"),
+	sterr.New("content of '%s' cannot be expressed in goml, element is dropped"),
+	sterr.New("css declaration '%s' is malformed"),
+	sterr.New("value '%s' of css property '%s' cannot be expressed in goss, property is dropped"),
+	sterr.New("unit of '%s' in css property '%s' is dropped"),
+	sterr.New("element '%s' is reserved in goml, it is renamed to '%s'"),
+	sterr.New("attribute without name is dropped"),
+}
+
+// rawElements contain text that is not html
+var rawElements = map[string]bool{
+	"script": true, "style": true, "template": true, "textarea": true,
+}
+
+// importer tokenizes html and builds goml tree
+type importer struct {
+	core.Parser
+	root        goml.Element
+	stack       []goml.Element
+	diagnostics []goml.Diagnostic
+}
+
+// Import converts html fragment into goml tree, returned element is a root with
+// no name. It understands elements, attributes, text with entities, void elements
+// and self-closing tags. Html is converted as follows:
+//
+//	text            collapsed whitespace, 'text' element
+//	<text>          'text_' element, so it is not confused with text
+//	class="a b"     class=["a" "b"]
+//	disabled        disabled="true"
+//	style="..."     Element.Style, see below
+//	data-id="1"     data_id="1", '-' and other invalid bytes become '_'
+//
+// Inline css is converted into goss.Style, '-' in property names and keywords
+// becomes '_'. Numbers in px or with no unit become ints or floats, other units
+// are dropped. Properties with colors, functions, strings or other values goss
+// cannot express are dropped. Everything that is not preserved exactly is reported
+// in returned diagnostics, their Err is *goml.ParseError so positions follow
+// the same conventions as parse errors. Import never fails.
+func Import(src []byte) (goml.Element, []goml.Diagnostic) {
+	im := importer{root: goml.NDiv()}
+	im.Restart(src)
+	im.Ch = 0
+
+	ok := im.next()
+	for ok {
+		if im.Ch == '<' && im.tagStart() {
+			ok = im.tag()
+		} else {
+			ok = im.text()
+		}
+	}
+
+	for len(im.stack) != 0 {
+		e := im.stack[len(im.stack)-1]
+		im.diagnoseAt(e.Span.Start, ErrImport.Unclosed.Args(e.Name))
+		im.pop()
+	}
+
+	return im.root, im.diagnostics
+}
+
+// next advances cursor and keeps track of lines
+func (im *importer) next() bool {
+	if im.I+1 >= len(im.Source) {
+		im.I = len(im.Source)
+		return false
+	}
+	if im.I >= 0 && im.Ch == '\n' {
+		im.NewLine()
+	}
+	return im.Advance()
+}
+
+// tagStart returns whether '<' under cursor starts tag, comment or declaration
+func (im *importer) tagStart() bool {
+	if im.I+1 >= len(im.Source) {
+		return false
+	}
+	ch := im.Source[im.I+1]
+	return ch == '/' || ch == '!' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+// skipTo moves cursor after the first occurrence of end, returns false if
+// there is none
+func (im *importer) skipTo(end string) bool {
+	for {
+		equal, ok := im.CheckSlice([]byte(end))
+		if !ok {
+			im.I = len(im.Source)
+			return false
+		}
+		if equal {
+			for i := 0; i < len(end); i++ {
+				im.next()
+			}
+			return true
+		}
+		im.next()
+	}
+}
+
+// skipSpace skips whitespace under cursor
+func (im *importer) skipSpace() bool {
+	for isSpace(im.Ch) {
+		if !im.next() {
+			return false
+		}
+	}
+	return true
+}
+
+// word reads bytes until whitespace or any of stop bytes
+func (im *importer) word(stop string) (string, bool) {
+	start := im.I
+	for !isSpace(im.Ch) && strings.IndexByte(stop, im.Ch) == -1 {
+		if !im.next() {
+			return string(im.Source[start:]), false
+		}
+	}
+	return string(im.Source[start:im.I]), true
+}
+
+// text reads text until tag, whitespace is collapsed
+func (im *importer) text() bool {
+	start := im.I
+	ok := im.next()
+	for ok && !(im.Ch == '<' && im.tagStart()) {
+		ok = im.next()
+	}
+
+	text := strings.Join(strings.Fields(stdhtml.UnescapeString(string(im.Source[start:im.I]))), " ")
+	if text != "" {
+		e := goml.NDiv()
+		e.Name = "text"
+		e.Attributes["text"] = []string{text}
+		im.add(e)
+	}
+	return ok
+}
+
+// tag reads tag, comment or declaration, cursor is on '<'
+func (im *importer) tag() bool {
+	pos := im.Pos()
+	im.next()
+	switch im.Ch {
+	case '!':
+		if equal, _ := im.CheckSlice([]byte("!--")); equal {
+			im.diagnoseAt(pos, ErrImport.Comment)
+			if !im.skipTo("-->") {
+				im.diagnoseAt(pos, ErrImport.Unterminated.Args("comment"))
+			}
+			return im.I < len(im.Source)
+		}
+		im.diagnoseAt(pos, ErrImport.Declaration)
+		if !im.skipTo(">") {
+			im.diagnoseAt(pos, ErrImport.Unterminated.Args("declaration"))
+		}
+		return im.I < len(im.Source)
+	case '/':
+		im.next()
+		name, _ := im.word(">")
+		if !im.skipTo(">") {
+			im.diagnoseAt(pos, ErrImport.Unterminated.Args("tag"))
+			return false
+		}
+		im.close(pos, tagName(strings.ToLower(name)))
+		return im.I < len(im.Source)
+	}
+
+	name, _ := im.word("/>")
+	name = strings.ToLower(name)
+	e := goml.NDiv()
+	e.Name = im.ident(pos, name)
+	if e.Name == "text" {
+		e.Name = tagName(e.Name)
+		im.diagnoseAt(pos, ErrImport.Reserved.Args(name, e.Name))
+	}
+	e.Span.Start = pos
+
+	closed, ok := im.attributes(&e)
+	if !ok {
+		im.diagnoseAt(pos, ErrImport.Unterminated.Args("tag"))
+		return false
+	}
+
+	if rawElements[name] && !closed {
+		im.diagnoseAt(pos, ErrImport.Raw.Args(name))
+		if !im.skipTo("</"+name) || !im.skipTo(">") {
+			im.diagnoseAt(pos, ErrImport.Unclosed.Args(name))
+		}
+		return im.I < len(im.Source)
+	}
+
+	if closed || voidElements[name] {
+		im.add(e)
+	} else {
+		im.stack = append(im.stack, e)
+	}
+	return im.I < len(im.Source)
+}
+
+// attributes reads attributes of element, cursor ends after '>', closed is true
+// if tag was self-closing
+func (im *importer) attributes(e *goml.Element) (closed, ok bool) {
+	for {
+		if !im.skipSpace() {
+			return
+		}
+		switch im.Ch {
+		case '>':
+			im.next()
+			return closed, true
+		case '/':
+			closed = true
+			if !im.next() {
+				return
+			}
+			continue
+		}
+		closed = false
+
+		pos := im.Pos()
+		name, more := im.word("=/>")
+		if !more || !im.skipSpace() {
+			return
+		}
+		name = strings.ToLower(name)
+
+		value, hasValue := "true", false
+		if im.Ch == '=' {
+			if !im.next() || !im.skipSpace() {
+				return
+			}
+			hasValue = true
+			switch im.Ch {
+			case '"', '\'':
+				quote := im.Ch
+				if !im.next() {
+					return
+				}
+				start := im.I
+				for im.Ch != quote {
+					if !im.next() {
+						return
+					}
+				}
+				value = string(im.Source[start:im.I])
+				im.next()
+			default:
+				if value, more = im.word(">"); !more {
+					return
+				}
+			}
+			value = stdhtml.UnescapeString(value)
+		}
+
+		if name == "" {
+			im.diagnoseAt(pos, ErrImport.Unnamed)
+			continue
+		}
+		key := im.ident(pos, name)
+		if _, ok := e.Attributes[key]; ok || key == "style" && e.Style != nil {
+			// first attribute wins as in html
+			continue
+		}
+		switch {
+		case key == "class" && hasValue:
+			e.Attributes[key] = strings.Fields(value)
+		case key == "style" && hasValue:
+			if style := im.css(pos, value); len(style) != 0 {
+				e.Style = style
+			}
+		default:
+			e.Attributes[key] = []string{value}
+		}
+	}
+}
+
+// close closes the element with given name, elements opened after it are closed
+// as well
+func (im *importer) close(pos core.Pos, name string) {
+	for i := len(im.stack) - 1; i >= 0; i-- {
+		if im.stack[i].Name != name {
+			continue
+		}
+		for len(im.stack) > i+1 {
+			e := im.stack[len(im.stack)-1]
+			im.diagnoseAt(e.Span.Start, ErrImport.Unclosed.Args(e.Name))
+			im.pop()
+		}
+		im.pop()
+		return
+	}
+	im.diagnoseAt(pos, ErrImport.Unexpected.Args(name))
+}
+
+// pop removes element from stack and adds it to its parent
+func (im *importer) pop() {
+	e := im.stack[len(im.stack)-1]
+	im.stack = im.stack[:len(im.stack)-1]
+	im.add(e)
+}
+
+// add appends element to element on stack top
+func (im *importer) add(e goml.Element) {
+	e.Span = core.Span{}
+	if len(im.stack) == 0 {
+		im.root.Children = append(im.root.Children, e)
+	} else {
+		parent := &im.stack[len(im.stack)-1]
+		parent.Children = append(parent.Children, e)
+	}
+}
+
+// ident turns name into valid goml identifier
+func (im *importer) ident(pos core.Pos, name string) string {
+	id := identifier(name)
+	if id != name {
+		im.diagnoseAt(pos, ErrImport.Name.Args(name, id))
+	}
+	return id
+}
+
+// css converts inline css into goss style
+func (im *importer) css(pos core.Pos, src string) goss.Style {
+	style := goss.Style{}
+o:
+	for _, decl := range strings.Split(src, ";") {
+		if strings.TrimSpace(decl) == "" {
+			continue
+		}
+		colon := strings.IndexByte(decl, ':')
+		if colon == -1 {
+			im.diagnoseAt(pos, ErrImport.CSS.Args(strings.TrimSpace(decl)))
+			continue
+		}
+		prop := strings.ToLower(strings.TrimSpace(decl[:colon]))
+		name := strings.ReplaceAll(prop, "-", "_")
+		if name == "" || identifier(name) != name {
+			im.diagnoseAt(pos, ErrImport.CSS.Args(strings.TrimSpace(decl)))
+			continue
+		}
+
+		var values []interface{}
+		for _, word := range strings.Fields(decl[colon+1:]) {
+			if v, unit, ok := number(word); ok {
+				if unit != "" && unit != "px" {
+					im.diagnoseAt(pos, ErrImport.Unit.Args(word, prop))
+				}
+				values = append(values, v)
+				continue
+			}
+			kw := strings.ReplaceAll(word, "-", "_")
+			if identifier(kw) != kw || core.IsNumStart(kw[0]) {
+				im.diagnoseAt(pos, ErrImport.Value.Args(word, prop))
+				continue o
+			}
+			values = append(values, kw)
+		}
+		if len(values) == 0 {
+			im.diagnoseAt(pos, ErrImport.CSS.Args(strings.TrimSpace(decl)))
+			continue
+		}
+		style[name] = values
+	}
+	return style
+}
+
+// diagnoseAt records diagnostic
+func (im *importer) diagnoseAt(pos core.Pos, err error) {
+	im.diagnostics = append(im.diagnostics, goml.Diagnostic{Pos: pos, Err: goml.NParseError("", im.Source, pos, err)})
+}
+
+// number parses css number with optional unit, value is int if number has no
+// fraction, float64 otherwise
+func number(word string) (value interface{}, unit string, ok bool) {
+	i := 0
+	if i < len(word) && (word[i] == '-' || word[i] == '+') {
+		i++
+	}
+	digits, dot := 0, false
+	for ; i < len(word); i++ {
+		if word[i] >= '0' && word[i] <= '9' {
+			digits++
+		} else if word[i] == '.' && !dot {
+			dot = true
+		} else {
+			break
+		}
+	}
+	if digits == 0 {
+		return
+	}
+	unit = word[i:]
+	for j := 0; j < len(unit); j++ {
+		if !(unit[j] >= 'a' && unit[j] <= 'z' || unit[j] >= 'A' && unit[j] <= 'Z' || unit[j] == '%') {
+			return
+		}
+	}
+
+	var err error
+	if dot {
+		value, err = strconv.ParseFloat(word[:i], 64)
+	} else {
+		value, err = strconv.Atoi(word[:i])
+	}
+	return value, strings.ToLower(unit), err == nil
+}
+
+// identifier replaces bytes that cannot be in goml identifier by '_'
+// tagName turns html tag name into name of goml element, 'text' is renamed
+// as goml uses it for text nodes
+func tagName(name string) string {
+	id := identifier(name)
+	if id == "text" {
+		id = "text_"
+	}
+	return id
+}
+
+func identifier(name string) string {
+	b := []byte(name)
+	for i, ch := range b {
+		if !(ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n' || ch == '\f'
+}
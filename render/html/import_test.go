@@ -0,0 +1,127 @@
+package html
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/jakubDoka/goml"
+	"github.com/jakubDoka/goml/goss"
+	"github.com/jakubDoka/sterr"
+)
+
+func TestImport(t *testing.T) {
+	src := `<!DOCTYPE html>
+<!-- header -->
+<DIV class="a  b" style="margin-top: 10px 1.5em; color: #fff; justify-content: space-between" data-id='1'>
+	Hello &amp; <b>bye</b>
+	<img src=a.png alt="x">
+	<br/>
+	<script>if (a < b) {}</script>
+	<input disabled value="v">
+	<p>unclosed
+</div>
+</span>`
+
+	root, diagnostics := Import([]byte(src))
+
+	var buff bytes.Buffer
+	if err := goml.Print(&buff, root); err != nil {
+		t.Error(err)
+		return
+	}
+	expected := `<div class=["a" "b"] data_id="1" style="justify_content: space_between; margin_top: 10 1.5f;">
+	Hello &
+	<b>bye</>
+	<img alt="x" src="a.png"/>
+	<br/>
+	<input disabled="true" value="v"/>
+	<p>unclosed</>
+</>
+`
+	if buff.String() != expected {
+		t.Error(buff.String())
+	}
+
+	errs := []sterr.Err{
+		ErrImport.Declaration,
+		ErrImport.Comment,
+		ErrImport.Unit,
+		ErrImport.Value,
+		ErrImport.Name,
+		ErrImport.Raw,
+		ErrImport.Unclosed,
+		ErrImport.Unexpected,
+	}
+	if len(diagnostics) != len(errs) {
+		t.Error(diagnostics)
+		return
+	}
+	for i, err := range errs {
+		if !errors.Is(diagnostics[i].Err, err) {
+			t.Error(i, diagnostics[i])
+		}
+	}
+	if pos := diagnostics[1].Pos; pos.Line != 1 || pos.Column != 0 {
+		t.Error(pos)
+	}
+	if pe := diagnostics[1].Err.(*goml.ParseError); pe.Line != 2 || pe.Column != 1 {
+		t.Error(pe)
+	}
+
+	// imported tree is valid goml
+	p := goml.NParser(&goss.Parser{})
+	p.AddDefinitions("div", "b", "img", "br", "input", "p")
+	res, err := p.Parse(buff.Bytes())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(res.Children) != 1 || res.Children[0].Style["margin_top"][1] != 1.5 {
+		t.Error(res)
+	}
+
+	buff.Reset()
+	if err := Render(&buff, root); err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Contains(buff.Bytes(), []byte(`style="justify-content: space-between; margin-top: 10px 1.5px;"`)) {
+		t.Error(buff.String())
+	}
+}
+
+func TestImportNames(t *testing.T) {
+	root, diagnostics := Import([]byte(`<svg><text x="1">label</text></svg><div ="x" a="b"></div>`))
+
+	errs := []sterr.Err{ErrImport.Reserved, ErrImport.Unnamed}
+	if len(diagnostics) != len(errs) {
+		t.Error(diagnostics)
+		return
+	}
+	for i, err := range errs {
+		if !errors.Is(diagnostics[i].Err, err) {
+			t.Error(i, diagnostics[i])
+		}
+	}
+
+	var buff bytes.Buffer
+	if err := goml.Print(&buff, root); err != nil {
+		t.Error(err)
+		return
+	}
+	expected := `<svg>
+	<text_ x="1">label</>
+</>
+<div a="b"/>
+`
+	if buff.String() != expected {
+		t.Error(buff.String())
+	}
+
+	p := goml.NParser(nil)
+	p.AddDefinitions("svg", "text_", "div")
+	if _, err := p.Parse(buff.Bytes()); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,168 @@
+package goml
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/jakubDoka/sterr"
+)
+
+// ErrSchema stores errors reported by Parser.Validate
+var ErrSchema = struct {
+	Attribute, Missing, Kind, Child, Text sterr.Err
+}{
+	sterr.New("element '%s' has no attribute '%s'"),
+	sterr.New("element '%s' requires attribute '%s'"),
+	sterr.New("attribute '%s' of element '%s' has to be %s"),
+	sterr.New("element '%s' cannot contain '%s'"),
+	sterr.New("element '%s' cannot contain text"),
+}
+
+// Kind is a kind of attribute value
+type Kind int
+
+// Kind variants
+const (
+	String Kind = iota
+	Int
+	Float
+	Bool
+	List
+)
+
+func (k Kind) String() string {
+	switch k {
+	case String:
+		return "single string"
+	case Int:
+		return "integer"
+	case Float:
+		return "floating point"
+	case Bool:
+		return "'true' or 'false'"
+	case List:
+		return "list"
+	}
+	return "unknown"
+}
+
+// AttribSchema describes value of attribute
+type AttribSchema struct {
+	Kind     Kind
+	Required bool
+}
+
+// Schema describes allowed content of element
+type Schema struct {
+	Name string
+	// Attributes lists allowed attributes, "style" is always allowed
+	Attributes map[string]AttribSchema
+	// Children lists names of elements that can be children, nil allows any element
+	Children []string
+	// Text allows element to contain text
+	Text bool
+}
+
+// AddSchemas adds definitions with schemas that Validate will check
+func (p *Parser) AddSchemas(schemas ...Schema) {
+	for _, s := range schemas {
+		p.defined[s.Name] = true
+		p.schemas[s.Name] = s
+	}
+}
+
+// Validate checks whether element and all its children conform to schemas,
+// elements that were added only by name are not checked. Diagnostics
+// point to the start of offending element and their Err is *ParseError same
+// as in diagnostics of ParseRecover. Validate does not know the source, so
+// Column of the error counts bytes, use NParseError with the source and
+// Diagnostic.Pos to count runes.
+func (p *Parser) Validate(e Element) (diagnostics []Diagnostic) {
+	report := func(e Element, err sterr.Err) {
+		diagnostics = append(diagnostics, Diagnostic{e.Span.Start, NParseError("", nil, e.Span.Start, err)})
+	}
+
+	var validate func(e Element)
+	validate = func(e Element) {
+		s, ok := p.schemas[e.Name]
+		if !ok {
+			for _, ch := range e.Children {
+				validate(ch)
+			}
+			return
+		}
+
+		names := make([]string, 0, len(e.Attributes))
+		for name := range e.Attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			as, ok := s.Attributes[name]
+			if !ok {
+				if name != "style" {
+					report(e, ErrSchema.Attribute.Args(e.Name, name))
+				}
+				continue
+			}
+			if !as.Kind.matches(e.Attributes[name]) {
+				report(e, ErrSchema.Kind.Args(name, e.Name, as.Kind))
+			}
+		}
+
+		names = names[:0]
+		for name, as := range s.Attributes {
+			if _, ok := e.Attributes[name]; !ok && as.Required {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			report(e, ErrSchema.Missing.Args(e.Name, name))
+		}
+
+		for _, ch := range e.Children {
+			if ch.Name == "text" {
+				if !s.Text {
+					report(ch, ErrSchema.Text.Args(e.Name))
+				}
+			} else if s.Children != nil && !contains(s.Children, ch.Name) {
+				report(ch, ErrSchema.Child.Args(e.Name, ch.Name))
+			}
+			validate(ch)
+		}
+	}
+
+	validate(e)
+	return
+}
+
+// matches returns whether values conform to kind
+func (k Kind) matches(values []string) bool {
+	if k == List {
+		return true
+	}
+	if len(values) != 1 {
+		return false
+	}
+
+	var err error
+	switch k {
+	case Int:
+		_, err = strconv.Atoi(values[0])
+	case Float:
+		_, err = strconv.ParseFloat(values[0], 64)
+	case Bool:
+		return values[0] == "true" || values[0] == "false"
+	}
+	return err == nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package goml
+
+import (
+	"testing"
+
+	"github.com/jakubDoka/goml/core"
+	"github.com/jakubDoka/sterr"
+)
+
+func TestValidate(t *testing.T) {
+	p := NParser(nil)
+	p.AddDefinitions("div")
+	p.AddSchemas(
+		Schema{
+			Name: "button",
+			Attributes: map[string]AttribSchema{
+				"onclick": {Kind: String, Required: true},
+				"width":   {Kind: Int},
+				"scale":   {Kind: Float},
+				"hidden":  {Kind: Bool},
+				"class":   {Kind: List},
+			},
+			Text: true,
+		},
+		Schema{
+			Name:     "list",
+			Children: []string{"button"},
+		},
+	)
+
+	d, err := p.Parse([]byte(`
+<list>
+	<button onclick="a" width="10" scale="1.5" hidden class=["a" "b"]>ok</>
+	<button onclik="a" width="1.5" scale="x" hidden="no"/>
+	<div>text</>
+	text
+</>`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	diags := p.Validate(d)
+	errs := []sterr.Err{
+		ErrSchema.Kind,
+		ErrSchema.Attribute,
+		ErrSchema.Kind,
+		ErrSchema.Kind,
+		ErrSchema.Missing,
+		ErrSchema.Child,
+		ErrSchema.Text,
+	}
+	if len(diags) != len(errs) {
+		t.Error(diags)
+		return
+	}
+	for i, e := range errs {
+		if !isKind(diags[i].Err, e) {
+			t.Error(i, diags[i])
+		}
+	}
+
+	core.TestEqual(t, diags[1].Pos, core.Pos{Offset: 82, Line: 3, Column: 1})
+	core.TestEqual(t, diags[5].Pos, core.Pos{Offset: 138, Line: 4, Column: 1})
+	if pe := diags[1].Err.(*ParseError); pe.Line != 4 || pe.Column != 2 || pe.Offset != 82 {
+		t.Error(pe)
+	}
+}
@@ -1,8 +1,10 @@
 package goml
 
 import (
+	"strings"
 	"unicode/utf8"
 
+	"github.com/jakubDoka/goml/core"
 	"github.com/jakubDoka/sterr"
 )
 
@@ -22,8 +24,13 @@ var ErrEscape = struct {
 	sterr.New("invalid escape identifier"),
 }
 
-// String parses started string into p.stringBuff
-func (p *Parser) string(ending byte, concatSpace bool) bool {
+// String parses started string, if string contains nothing that has to be decoded,
+// it is sliced from source, otherwise it is decoded into p.stringBuff
+func (p *Parser) string(ending byte, concatSpace bool) (string, bool) {
+	if str, ok := p.sourceString(ending, concatSpace); ok {
+		return str, true
+	}
+
 	p.stringBuff = p.stringBuff[:0]
 	var r rune
 	var fin bool
@@ -31,7 +38,7 @@ func (p *Parser) string(ending byte, concatSpace bool) bool {
 		afterSpace := r == ' '
 		r, fin = p.char(ending)
 		if p.Failed() {
-			return false
+			return "", false
 		}
 		if fin {
 			break
@@ -40,6 +47,9 @@ func (p *Parser) string(ending byte, concatSpace bool) bool {
 			continue
 		}
 		p.stringBuff = append(p.stringBuff, r)
+		if concatSpace && r != ' ' {
+			p.textEnd = p.End()
+		}
 	}
 
 	if concatSpace { // cutting off the invisible characters
@@ -58,7 +68,54 @@ func (p *Parser) string(ending byte, concatSpace bool) bool {
 			p.stringBuff = p.stringBuff[:l+1]
 		}
 	}
-	return true
+	return string(p.stringBuff), true
+}
+
+// sourceString returns string that starts after p.Ch as slice of source if it
+// contains no escapes, templates, invisible characters other then space and
+// when concatSpace is true, no repeated spaces. Cursor ends where p.string would
+// leave it.
+func (p *Parser) sourceString(ending byte, concatSpace bool) (string, bool) {
+	start := p.I + 1
+	end := start
+	ascii := true
+o:
+	for ; end < len(p.Source); end++ {
+		switch ch := p.Source[end]; ch {
+		case ending:
+			break o
+		case '\\', '{', '\n', '\t', '\r':
+			return "", false
+		case ' ':
+			if concatSpace && end > start && p.Source[end-1] == ' ' {
+				return "", false
+			}
+		default:
+			ascii = ascii && ch < utf8.RuneSelf
+		}
+	}
+	if end == len(p.Source) && (ending != '<' || end == start) {
+		return "", false
+	}
+
+	str := p.src[start:end]
+	if !ascii && !utf8.ValidString(str) {
+		return "", false
+	}
+
+	if concatSpace {
+		str = strings.TrimRight(str, " ")
+		offset := start + len(str)
+		p.textEnd = core.Pos{Offset: offset, Line: p.Line, Column: offset - p.LineStart}
+	}
+
+	if end == len(p.Source) {
+		p.Set(end - 1)
+	} else {
+		p.Set(end)
+		p.Advance()
+	}
+	return str, true
 }
 
 // char turns a go string syntax to its data representation
@@ -85,7 +142,10 @@ func (p *Parser) char(ending byte) (r rune, end bool) {
 	switch p.Ch {
 	case '\\':
 	// these runes are ignored, to add actual ones syntax has to be used
-	case '\n', '\t', '\r':
+	case '\n':
+		p.NewLine()
+		return ' ', false
+	case '\t', '\r':
 		return ' ', false
 	case '{':
 		return p.stringTemplate(), false
@@ -209,16 +269,13 @@ func (p *Parser) stringTemplate() (r rune) {
 	if p.Ch == '{' {
 		return '{'
 	}
-	//p.Degrade() // we advanced to get whats behind '{' so wh have to step back for template to read whole ident
-	start := p.I - 1
 	if !p.template(&p.parsed, stringTemplate) {
 		return
 	}
 	p.Degrade() // Degrade again or we will end up with '}}'
-	for i := start; i < p.I; {
-		r, size := utf8.DecodeRune(p.Source[i:])
-		p.stringBuff = append(p.stringBuff, r)
-		i += size
-	}
+
+	// only name is kept so placeholder can be found regardless of default value
+	p.stringBuff = append(p.stringBuff, '{')
+	p.stringBuff = append(p.stringBuff, []rune(p.parsed.prefabData[len(p.parsed.prefabData)-1].Name)...)
 	return '}'
 }
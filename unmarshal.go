@@ -0,0 +1,284 @@
+package goml
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jakubDoka/goml/goss"
+	"github.com/jakubDoka/sterr"
+)
+
+// ErrUnmarshal stores errors returned by Unmarshal
+var ErrUnmarshal = struct {
+	Target, Unsupported, Value, Count, Child sterr.Err
+}{
+	sterr.New("target has to be non-nil pointer to struct, got %s"),
+	sterr.New("field '%s' has unsupported type %s"),
+	sterr.New("attribute '%s' of element '%s' cannot be decoded into %s"),
+	sterr.New("field needs exactly one value, got %d"),
+	sterr.New("child '%s' of element '%s'"),
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	styleType    = reflect.TypeOf(goss.Style(nil))
+	elementType  = reflect.TypeOf(Element{})
+	textType     = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Unmarshal decodes element into struct v points to. Only fields with goml tag
+// are decoded, tag holds attribute name and optional flags:
+//
+//	Width   int           `goml:"width"`        // attribute
+//	Classes []string      `goml:"class"`        // list attribute
+//	Style   goss.Style    `goml:"style"`        // Element.Style
+//	Label   string        `goml:",text"`        // text of the element
+//	Buttons []Button      `goml:"button,child"` // children named button
+//	Header  *Header       `goml:"header,child"` // first child named header
+//
+// Supported attribute types are strings, booleans, integers, floats, time.Duration,
+// types implementing encoding.TextUnmarshaler, pointers to them and slices of them.
+// Child fields can be structs, Elements, pointers to them or slices of them. Fields
+// whose attribute is missing are left untouched so they can hold default values.
+func Unmarshal(e Element, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrUnmarshal.Target.Args(reflect.TypeOf(v))
+	}
+	return unmarshal(e, rv.Elem())
+}
+
+func unmarshal(e Element, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, ok := field.Tag.Lookup("goml")
+		if !ok || tag == "-" || field.PkgPath != "" {
+			continue
+		}
+		name, flags := parseTag(tag)
+		fv := rv.Field(i)
+
+		switch {
+		case hasFlag(flags, "child"):
+			if err := unmarshalChildren(e, name, fv); err != nil {
+				return err
+			}
+		case hasFlag(flags, "text"):
+			if fv.Kind() != reflect.String {
+				return ErrUnmarshal.Unsupported.Args(field.Name, field.Type)
+			}
+			fv.SetString(e.Text())
+		case field.Type == styleType:
+			if e.Style != nil {
+				fv.Set(reflect.ValueOf(e.Style))
+			}
+		default:
+			values, ok := e.Attributes[name]
+			if !ok {
+				continue
+			}
+			if !supported(field.Type) {
+				return ErrUnmarshal.Unsupported.Args(field.Name, field.Type)
+			}
+			if err := setValues(fv, values); err != nil {
+				return ErrUnmarshal.Value.Args(name, e.Name, field.Type).Wrap(err)
+			}
+		}
+	}
+	return nil
+}
+
+// Text returns concatenated text of direct text children
+func (e Element) Text() string {
+	var sb strings.Builder
+	for _, ch := range e.Children {
+		if ch.Name == "text" && len(ch.Attributes["text"]) != 0 {
+			if sb.Len() != 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(ch.Attributes["text"][0])
+		}
+	}
+	return sb.String()
+}
+
+// parseTag splits tag into name and comma separated flags
+func parseTag(tag string) (name, flags string) {
+	if i := strings.IndexByte(tag, ','); i != -1 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+// hasFlag returns whether flags contain flag
+func hasFlag(flags, flag string) bool {
+	for flags != "" {
+		var f string
+		if i := strings.IndexByte(flags, ','); i != -1 {
+			f, flags = flags[:i], flags[i+1:]
+		} else {
+			f, flags = flags, ""
+		}
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// unmarshalChildren decodes children with given name into fv
+func unmarshalChildren(e Element, name string, fv reflect.Value) error {
+	ft := fv.Type()
+	if ft.Kind() == reflect.Slice {
+		if !childType(ft.Elem()) {
+			return ErrUnmarshal.Unsupported.Args(name, ft)
+		}
+		slice := reflect.MakeSlice(ft, 0, 0)
+		for _, ch := range e.Children {
+			if ch.Name != name {
+				continue
+			}
+			item := reflect.New(ft.Elem()).Elem()
+			if err := setChild(ch, item); err != nil {
+				return ErrUnmarshal.Child.Args(name, e.Name).Wrap(err)
+			}
+			slice = reflect.Append(slice, item)
+		}
+		fv.Set(slice)
+		return nil
+	}
+
+	if !childType(ft) {
+		return ErrUnmarshal.Unsupported.Args(name, ft)
+	}
+	for _, ch := range e.Children {
+		if ch.Name == name {
+			if err := setChild(ch, fv); err != nil {
+				return ErrUnmarshal.Child.Args(name, e.Name).Wrap(err)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// childType returns whether type can hold a child
+func childType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// setChild decodes element into fv
+func setChild(e Element, fv reflect.Value) error {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+	if fv.Type() == elementType {
+		fv.Set(reflect.ValueOf(e))
+		return nil
+	}
+	return unmarshal(e, fv)
+}
+
+// supported returns whether attribute can be decoded into type
+func supported(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(textType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// setValues stores attribute values into fv, slices take all values
+// other types require exactly one
+func setValues(fv reflect.Value, values []string) error {
+	if fv.Kind() == reflect.Slice && !reflect.PtrTo(fv.Type()).Implements(textType) {
+		slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := setValue(slice.Index(i), v); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+
+	if len(values) != 1 {
+		return ErrUnmarshal.Count.Args(len(values))
+	}
+	return setValue(fv, values[0])
+}
+
+// setValue parses value into fv
+func setValue(fv reflect.Value, value string) error {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+
+	if u, ok := fv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package goml
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jakubDoka/goml/core"
+	"github.com/jakubDoka/goml/goss"
+	"github.com/jakubDoka/sterr"
+)
+
+type testButton struct {
+	OnClick string        `goml:"onclick"`
+	Width   int           `goml:"width"`
+	Scale   float32       `goml:"scale"`
+	Hidden  bool          `goml:"hidden"`
+	Delay   time.Duration `goml:"delay"`
+	Classes []string      `goml:"class"`
+	Sizes   []uint8       `goml:"sizes"`
+	Opt     *int          `goml:"opt"`
+	Label   string        `goml:",text"`
+	Ignored string
+}
+
+type testList struct {
+	Name    string       `goml:"name"`
+	Style   goss.Style   `goml:"style"`
+	Buttons []testButton `goml:"button,child"`
+	First   *testButton  `goml:"button,child"`
+	Raw     Element      `goml:"div,child"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	p := NParser(&goss.Parser{})
+	p.AddDefinitions("list", "button", "div")
+	d, err := p.Parse([]byte(`
+<list name="l" style="a: 1;">
+	<button onclick="a" width="10" scale="1.5" hidden delay="1s" class=["a" "b"] sizes=["1" "2"] opt="3">ok</>
+	<button width="-2"/>
+	<div/>
+</>`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	three := 3
+	l := testList{Name: "default"}
+	if err := Unmarshal(d.Children[0], &l); err != nil {
+		t.Error(err)
+		return
+	}
+
+	l.Raw.Span = core.Span{}
+	first := testButton{
+		OnClick: "a",
+		Width:   10,
+		Scale:   1.5,
+		Hidden:  true,
+		Delay:   time.Second,
+		Classes: []string{"a", "b"},
+		Sizes:   []uint8{1, 2},
+		Opt:     &three,
+		Label:   "ok",
+	}
+	core.TestEqual(t, l, testList{
+		Name:    "l",
+		Style:   goss.Style{"a": {1}},
+		Buttons: []testButton{first, {Width: -2}},
+		First:   &first,
+		Raw:     Element{Name: "div", Attributes: Attribs{}},
+	})
+
+	testCases := []struct {
+		desc, input string
+		err         sterr.Err
+	}{
+		{
+			desc:  "int",
+			input: `<button width="1.5"/>`,
+			err:   ErrUnmarshal.Value,
+		},
+		{
+			desc:  "count",
+			input: `<button width=["1" "2"]/>`,
+			err:   ErrUnmarshal.Value,
+		},
+		{
+			desc:  "child",
+			input: `<list><button hidden="no"/></>`,
+			err:   ErrUnmarshal.Child,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			d, err := p.Parse([]byte(tC.input))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			var err2 error
+			if d.Children[0].Name == "list" {
+				err2 = Unmarshal(d.Children[0], &testList{})
+			} else {
+				err2 = Unmarshal(d.Children[0], &testButton{})
+			}
+			if !tC.err.SameSurface(err2) {
+				t.Error(err2)
+			}
+		})
+	}
+
+	if !ErrUnmarshal.Target.SameSurface(Unmarshal(d, testList{})) {
+		t.Error("target")
+	}
+}
@@ -0,0 +1,89 @@
+package goml
+
+import "errors"
+
+// SkipChildren can be returned from Visitor.Enter to skip children of element,
+// Leave is still called for the element
+var SkipChildren = errors.New("skip children")
+
+// Visitor is notified about elements during Walk, path holds ancestors of element
+// starting with the element passed to Walk, slice is reused between calls so it
+// has to be copied if retained
+type Visitor interface {
+	Enter(e *Element, path []*Element) error
+	Leave(e *Element, path []*Element) error
+}
+
+// VisitorFuncs implements Visitor with functions, nil function is ignored
+type VisitorFuncs struct {
+	OnEnter, OnLeave func(e *Element, path []*Element) error
+}
+
+// Enter implements Visitor interface
+func (v VisitorFuncs) Enter(e *Element, path []*Element) error {
+	if v.OnEnter == nil {
+		return nil
+	}
+	return v.OnEnter(e, path)
+}
+
+// Leave implements Visitor interface
+func (v VisitorFuncs) Leave(e *Element, path []*Element) error {
+	if v.OnLeave == nil {
+		return nil
+	}
+	return v.OnLeave(e, path)
+}
+
+// Walk traverses tree in document order calling v.Enter before children of element
+// are visited and v.Leave after. Walking stops at first error other then
+// SkipChildren and the error is returned. Elements can be modified in place but
+// adding or removing children of element that is being walked is not allowed.
+func Walk(e *Element, v Visitor) error {
+	return walk(e, make([]*Element, 0, 8), v)
+}
+
+func walk(e *Element, path []*Element, v Visitor) error {
+	err := v.Enter(e, path)
+	if err != nil && err != SkipChildren {
+		return err
+	}
+	if err == nil {
+		path = append(path, e)
+		for i := range e.Children {
+			if err := walk(&e.Children[i], path, v); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+	}
+	return v.Leave(e, path)
+}
+
+// Transform builds new tree by replacing each descendant of e with elements f
+// returns for it, returning nil drops the element and returning more elements
+// inserts them in its place. Tree is processed bottom up so f receives elements
+// whose children are already transformed, path holds ancestors as they are in
+// the input tree. Root itself is not passed to f. Input tree is not modified unless f
+// modifies Attributes or Style of elements it receives, those are shared.
+func Transform(e Element, f func(e Element, path []*Element) []Element) Element {
+	return transform(e, make([]*Element, 0, 8), f)
+}
+
+func transform(e Element, path []*Element, f func(e Element, path []*Element) []Element) Element {
+	if len(e.Children) == 0 {
+		return e
+	}
+
+	path = append(path, &e)
+	children := make([]Element, 0, len(e.Children))
+	for _, ch := range e.Children {
+		ch = transform(ch, path, f)
+		children = append(children, f(ch, path)...)
+	}
+	if len(children) == 0 {
+		children = nil
+	}
+	e.Children = children
+	return e
+}
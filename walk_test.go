@@ -0,0 +1,93 @@
+package goml
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	p := NParser(nil)
+	p.AddDefinitions("div", "button", "span")
+	root, err := p.Parse([]byte(`<div><button/><span skip="true"><button/></></><span/>`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var events []string
+	err = Walk(&root, VisitorFuncs{
+		OnEnter: func(e *Element, path []*Element) error {
+			events = append(events, strings.Repeat(" ", len(path))+"+"+e.Name)
+			if _, ok := e.Attributes["skip"]; ok {
+				return SkipChildren
+			}
+			return nil
+		},
+		OnLeave: func(e *Element, path []*Element) error {
+			events = append(events, strings.Repeat(" ", len(path))+"-"+e.Name)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	expected := "+| +div|  +button|  -button|  +span|  -span| -div| +span| -span|-"
+	if res := strings.Join(events, "|"); res != expected {
+		t.Error(res)
+	}
+
+	stop := errors.New("stop")
+	count := 0
+	err = Walk(&root, VisitorFuncs{OnEnter: func(e *Element, path []*Element) error {
+		count++
+		if e.Name == "button" {
+			return stop
+		}
+		return nil
+	}})
+	if err != stop || count != 3 {
+		t.Error(err, count)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	p := NParser(nil)
+	p.AddDefinitions("div", "button", "span", "flag", "b")
+	src := []byte(`<div><flag><button/></><span drop="true"/><b/></>`)
+	root, err := p.Parse(src)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	res := Transform(root, func(e Element, path []*Element) []Element {
+		switch {
+		case e.Name == "flag":
+			// unwrap
+			return e.Children
+		case e.Attributes["drop"] != nil:
+			return nil
+		case e.Name == "b":
+			text := NDiv()
+			text.Name = "text"
+			text.Attributes["text"] = []string{"in " + path[len(path)-1].Name}
+			return []Element{e, text}
+		}
+		return []Element{e}
+	})
+
+	var buff bytes.Buffer
+	Print(&buff, res)
+	if buff.String() != "<div>\n\t<button/>\n\t<b/>\n\tin div\n</>\n" {
+		t.Error(buff.String())
+	}
+
+	buff.Reset()
+	Print(&buff, root)
+	if buff.String() != "<div>\n\t<flag>\n\t\t<button/>\n\t</>\n\t<span drop=\"true\"/>\n\t<b/>\n</>\n" {
+		t.Error(buff.String())
+	}
+}
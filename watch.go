@@ -0,0 +1,251 @@
+package goml
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jakubDoka/goml/goss"
+)
+
+// Update describes result of reloading one file
+type Update struct {
+	// Path of reloaded file
+	Path string
+	// Root is the parsed tree of .goml file, if reloading failed, it is the last
+	// tree that was parsed successfully
+	Root Element
+	// Styles are parsed from .goss file, if reloading failed, they are the last
+	// successfully parsed ones
+	Styles goss.Styles
+	// Err is error that prevented reloading, syntax errors of both file kinds
+	// are *ParseError with File set to Path
+	Err error
+	// Prefabs are names of prefabs file added, removed or modified, prefabs of
+	// deleted file are removed
+	Prefabs []string
+}
+
+// watchedFile is file state stored by Watcher
+type watchedFile struct {
+	path    string
+	modTime time.Time
+	size    int64
+	missing bool
+
+	root    Element
+	styles  goss.Styles
+	defines []string // prefabs defined by file
+}
+
+// Watcher reloads .goml and .goss files when they change. Files are polled for
+// modification time and size. When prefab definitions change, all .goml files
+// are parsed again so they use new prefabs. Parser should not be used by anything
+// else while watcher is running.
+type Watcher struct {
+	// Interval between polls done by Run
+	Interval time.Duration
+
+	parser      *Parser
+	gs          goss.Parser
+	files       []*watchedFile
+	subscribers []func(Update)
+	mutex       sync.Mutex
+}
+
+// NWatcher creates watcher that parses .goml files with p
+func NWatcher(p *Parser) *Watcher {
+	return &Watcher{
+		Interval: 500 * time.Millisecond,
+		parser:   p,
+	}
+}
+
+// AddFiles adds files to watch, .goml files are parsed in order they were added,
+// so prefab libraries have to be added before files that use them. Files are
+// loaded on next Poll.
+func (w *Watcher) AddFiles(paths ...string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for _, path := range paths {
+		w.files = append(w.files, &watchedFile{path: path})
+	}
+}
+
+// Subscribe registers function that is called with every Update Poll produces,
+// it is called from goroutine that polls and can add files or subscribers, they
+// take effect on next Poll
+func (w *Watcher) Subscribe(f func(Update)) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.subscribers = append(w.subscribers, f)
+}
+
+// Run polls files in w.Interval until stop is closed
+func (w *Watcher) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		w.Poll()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll reloads files that changed since last poll, first poll loads all files.
+// Updates are passed to subscribers and returned.
+func (w *Watcher) Poll() []Update {
+	w.mutex.Lock()
+
+	var updates []Update
+	reloaded := make([]bool, len(w.files))
+	prefabsChanged := false
+	for i, f := range w.files {
+		changed, err := f.stat()
+		if !changed {
+			continue
+		}
+		reloaded[i] = true
+		if err != nil {
+			u := Update{Path: f.path, Root: f.root, Styles: f.styles, Err: err}
+			if len(f.defines) != 0 {
+				// prefabs of missing file are dropped so files using them fail
+				w.parser.RemovePrefabs(f.defines...)
+				u.Prefabs, f.defines = f.defines, nil
+				prefabsChanged = true
+			}
+			updates = append(updates, u)
+			continue
+		}
+		u := w.reload(f)
+		prefabsChanged = prefabsChanged || len(u.Prefabs) != 0
+		updates = append(updates, u)
+	}
+
+	if prefabsChanged {
+		for i, f := range w.files {
+			if !reloaded[i] && !f.missing && filepath.Ext(f.path) != ".goss" {
+				updates = append(updates, w.reload(f))
+			}
+		}
+	}
+
+	// subscribers are called without lock so they can use the watcher
+	subscribers := make([]func(Update), len(w.subscribers))
+	copy(subscribers, w.subscribers)
+	w.mutex.Unlock()
+
+	for _, u := range updates {
+		for _, s := range subscribers {
+			s(u)
+		}
+	}
+	return updates
+}
+
+// stat updates file stamp and returns whether file has to be reloaded, err is
+// returned if file started missing
+func (f *watchedFile) stat() (changed bool, err error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		if f.missing {
+			return false, nil
+		}
+		f.missing = true
+		return true, err
+	}
+	changed = f.missing || f.modTime.IsZero() || !info.ModTime().Equal(f.modTime) || info.Size() != f.size
+	f.missing = false
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	return changed, nil
+}
+
+// reload parses file again, on failure last good state is kept
+func (w *Watcher) reload(f *watchedFile) Update {
+	u := Update{Path: f.path, Root: f.root, Styles: f.styles}
+	src, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		u.Err = err
+		return u
+	}
+
+	if filepath.Ext(f.path) == ".goss" {
+		styles, err := w.gs.Parse(src)
+		if err != nil {
+			if w.gs.ErrKind != nil {
+				err = w.gs.ErrKind
+			}
+			u.Err = NParseError(f.path, src, w.gs.ErrPos, err)
+			return u
+		}
+		f.styles = styles
+		u.Styles = styles
+		return u
+	}
+
+	p := w.parser
+	old := make(map[string]Element, len(f.defines))
+	for _, name := range f.defines {
+		old[name] = p.prefabs[name]
+	}
+	p.RemovePrefabs(f.defines...)
+	before := make(map[string]bool, len(p.prefabs))
+	for name := range p.prefabs {
+		before[name] = true
+	}
+
+	root, err := p.Parse(src)
+	var defines []string
+	for name := range p.prefabs {
+		if !before[name] {
+			defines = append(defines, name)
+		}
+	}
+	if err != nil {
+		if pe, ok := err.(*ParseError); ok {
+			pe.File = f.path
+		}
+		p.RemovePrefabs(defines...)
+		for name, e := range old {
+			p.prefabs[name] = e
+		}
+		u.Err = err
+		return u
+	}
+
+	sort.Strings(defines)
+	for name := range old {
+		if _, ok := p.prefabs[name]; !ok {
+			u.Prefabs = append(u.Prefabs, name)
+		}
+	}
+	var pr Printer
+	var a, b bytes.Buffer
+	for _, name := range defines {
+		e, ok := old[name]
+		if ok {
+			a.Reset()
+			b.Reset()
+			pr.PrintPrefab(&a, e)
+			pr.PrintPrefab(&b, p.prefabs[name])
+			if bytes.Equal(a.Bytes(), b.Bytes()) {
+				continue
+			}
+		}
+		u.Prefabs = append(u.Prefabs, name)
+	}
+	sort.Strings(u.Prefabs)
+
+	f.defines = defines
+	f.root = root
+	u.Root = root
+	return u
+}
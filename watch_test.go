@@ -0,0 +1,193 @@
+package goml
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goml")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	stamp := time.Now()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// modification time has to change even on file systems with coarse timestamps
+		stamp = stamp.Add(time.Second)
+		if err := os.Chtimes(path, stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("lib.goml", `<!a><div/><!/><!b><span/><!/>`)
+	write("main.goml", `<a/><b/>`)
+	write("style.goss", `x{a: b;}`)
+
+	p := NParser(nil)
+	p.AddDefinitions("div", "span", "button")
+	w := NWatcher(p)
+	w.AddFiles(filepath.Join(dir, "lib.goml"), filepath.Join(dir, "main.goml"), filepath.Join(dir, "style.goss"))
+
+	var notified int
+	w.Subscribe(func(u Update) { notified++ })
+
+	names := func(e Element) (res []string) {
+		for _, ch := range e.Children {
+			res = append(res, ch.Name)
+		}
+		return
+	}
+
+	updates := w.Poll()
+	if len(updates) != 3 || notified != 3 {
+		t.Error(updates)
+		return
+	}
+	checkStrings(t, updates[0].Prefabs, "a", "b")
+	checkStrings(t, names(updates[1].Root), "div", "span")
+	if updates[2].Styles["x"] == nil {
+		t.Error(updates[2])
+	}
+
+	if updates := w.Poll(); len(updates) != 0 {
+		t.Error(updates)
+	}
+
+	// changed prefab causes reload of files using it
+	write("lib.goml", `<!a><button/><!/><!b><span/><!/><!c><div/><!/>`)
+	updates = w.Poll()
+	if len(updates) != 2 {
+		t.Error(updates)
+		return
+	}
+	checkStrings(t, updates[0].Prefabs, "a", "c")
+	checkStrings(t, names(updates[1].Root), "button", "span")
+
+	// broken file keeps last good state
+	write("main.goml", `<a/><b>`)
+	updates = w.Poll()
+	if len(updates) != 1 || updates[0].Err == nil {
+		t.Error(updates)
+		return
+	}
+	checkStrings(t, names(updates[0].Root), "button", "span")
+
+	write("lib.goml", `<!a><div/>`)
+	updates = w.Poll()
+	if len(updates) != 1 || updates[0].Err == nil {
+		t.Error(updates)
+		return
+	}
+	if _, ok := p.prefabs["c"]; !ok {
+		t.Error("prefabs of broken library have to be kept")
+	}
+
+	write("lib.goml", `<!a><div/><!/>`)
+	write("main.goml", `<a/>`)
+	updates = w.Poll()
+	if len(updates) != 2 || updates[0].Err != nil || updates[1].Err != nil {
+		t.Error(updates)
+		return
+	}
+	checkStrings(t, updates[0].Prefabs, "a", "b", "c")
+	checkStrings(t, names(updates[1].Root), "div")
+
+	os.Remove(filepath.Join(dir, "style.goss"))
+	if updates := w.Poll(); len(updates) != 1 || updates[0].Err == nil || updates[0].Styles["x"] == nil {
+		t.Error(updates)
+	}
+	if updates := w.Poll(); len(updates) != 0 {
+		t.Error(updates)
+	}
+
+	write("style.goss", `x{a: b}`)
+	updates = w.Poll()
+	var pe *ParseError
+	if len(updates) != 1 || !errors.As(updates[0].Err, &pe) || pe.File != filepath.Join(dir, "style.goss") || pe.Line != 1 {
+		t.Error(updates)
+	}
+
+	// prefabs of deleted library are dropped and files using them fail
+	os.Remove(filepath.Join(dir, "lib.goml"))
+	updates = w.Poll()
+	if len(updates) != 2 || updates[1].Path != filepath.Join(dir, "main.goml") || !errors.Is(updates[1].Err, ErrUnknown) {
+		t.Error(updates)
+		return
+	}
+	checkStrings(t, updates[0].Prefabs, "a")
+	if _, ok := p.prefabs["a"]; ok {
+		t.Error("prefabs of deleted library have to be dropped")
+	}
+}
+
+func checkStrings(t *testing.T, got []string, expected ...string) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Error(got, expected)
+		return
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Error(got, expected)
+			return
+		}
+	}
+}
+
+func TestWatcherSubscriberReentry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goml")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	first, second := filepath.Join(dir, "a.goml"), filepath.Join(dir, "b.goml")
+	for _, path := range []string{first, second} {
+		if err := ioutil.WriteFile(path, []byte(`<div/>`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := NParser(nil)
+	p.AddDefinitions("div")
+	w := NWatcher(p)
+	w.AddFiles(first)
+
+	var paths []string
+	w.Subscribe(func(u Update) {
+		paths = append(paths, u.Path)
+		if u.Path == first {
+			// watcher is not locked while subscribers run
+			w.AddFiles(second)
+			w.Subscribe(func(Update) {})
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		w.Poll()
+		w.Poll()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("subscriber deadlocked")
+	}
+
+	if len(paths) != 2 || paths[0] != first || paths[1] != second {
+		t.Error(paths)
+	}
+}